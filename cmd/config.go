@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	configPath      string
+	configOverrides []string
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the application configuration",
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the effective configuration with secrets redacted",
+	Long: `Print the effective configuration after applying the config file, APP_*
+environment variables and flags. Secret values are redacted; secrets given as
+references such as file:///run/secrets/db_password are shown as configured.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		defer enc.Close()
+
+		return enc.Encode(cfg)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the configuration and report every problem found",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if _, err := loadConfig(cmd); err != nil {
+			return err
+		}
+
+		fmt.Println("configuration is valid")
+		return nil
+	},
+}
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&configPath, "config", "", "config file (default ./config.yaml or $APP_CONFIG)")
+	flags.String("mode", "", "application mode: debug, test or release")
+	flags.Int("port", 0, "port the API listens on")
+	flags.StringArrayVar(&configOverrides, "set", nil, "override a config key, e.g. --set database.host=db")
+
+	configCmd.AddCommand(configPrintCmd, configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// loadConfig layers the flags of cmd on top of defaults, the config file and
+// APP_* environment variables.
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
+	opts, err := loadOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return config.Load(opts)
+}
+
+// loadOptions describes where the configuration of cmd comes from, so it can
+// be loaded again on reload.
+func loadOptions(cmd *cobra.Command) (config.LoadOptions, error) {
+	overrides := make(map[string]string)
+	for _, override := range configOverrides {
+		key, value, ok := strings.Cut(override, "=")
+		if !ok {
+			return config.LoadOptions{}, fmt.Errorf("invalid --set %q: expected key=value", key)
+		}
+		overrides[key] = value
+	}
+
+	for _, name := range []string{"mode", "port"} {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			overrides[name] = flag.Value.String()
+		}
+	}
+
+	return config.LoadOptions{
+		Path:      configPath,
+		EnvPrefix: config.DefaultEnvPrefix,
+		Overrides: overrides,
+	}, nil
+}
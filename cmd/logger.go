@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/internal/telemetry"
+)
+
+// newLogger builds the application logger. Its level is read from level on
+// every record so it can be changed while the server runs.
+func newLogger(w io.Writer, cfg config.LogConfig, level *slog.LevelVar) *slog.Logger {
+	level.Set(cfg.SlogLevel())
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch cfg.Format {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	default:
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	return slog.New(telemetry.NewTraceHandler(handler))
+}
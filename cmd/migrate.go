@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/internal/migration"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+var (
+	migrationDir  string
+	migrationType string
+)
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database migrations",
+	Long: `Apply, roll back and inspect the database migrations embedded in the
+binary. The database and its type are taken from the configuration.`,
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: withDatabase(func(cmd *cobra.Command, cfg *config.Config, db *gorm.DB) error {
+		return migration.MigrateUp(db, cfg.Database.Type, cfg.Migration.LockTimeout)
+	}),
+}
+
+var migrateUpToCmd = &cobra.Command{
+	Use:   "up-to VERSION",
+	Short: "Apply pending migrations up to and including VERSION",
+	Args:  cobra.ExactArgs(1),
+	RunE: withDatabase(func(cmd *cobra.Command, cfg *config.Config, db *gorm.DB) error {
+		version, err := parseVersion(cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		return migration.MigrateUpTo(db, cfg.Database.Type, version, cfg.Migration.LockTimeout)
+	}),
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back the most recently applied migration",
+	Args:  cobra.NoArgs,
+	RunE: withDatabase(func(cmd *cobra.Command, cfg *config.Config, db *gorm.DB) error {
+		return migration.MigrateDown(db, cfg.Database.Type, cfg.Migration.LockTimeout)
+	}),
+}
+
+var migrateDownToCmd = &cobra.Command{
+	Use:   "down-to VERSION",
+	Short: "Roll back migrations until VERSION is the latest applied; 0 rolls back everything",
+	Args:  cobra.ExactArgs(1),
+	RunE: withDatabase(func(cmd *cobra.Command, cfg *config.Config, db *gorm.DB) error {
+		version, err := parseVersion(cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		return migration.MigrateDownTo(db, cfg.Database.Type, version, cfg.Migration.LockTimeout)
+	}),
+}
+
+var migrateRedoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "Roll back the most recently applied migration and apply it again",
+	Args:  cobra.NoArgs,
+	RunE: withDatabase(func(cmd *cobra.Command, cfg *config.Config, db *gorm.DB) error {
+		return migration.Redo(db, cfg.Database.Type, cfg.Migration.LockTimeout)
+	}),
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "List every migration and when it was applied",
+	Args:  cobra.NoArgs,
+	RunE: withDatabase(func(cmd *cobra.Command, cfg *config.Config, db *gorm.DB) error {
+		return migration.Status(db, cfg.Database.Type, cmd.OutOrStdout())
+	}),
+}
+
+var migrateVersionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the latest applied migration version",
+	Args:  cobra.NoArgs,
+	RunE: withDatabase(func(cmd *cobra.Command, cfg *config.Config, db *gorm.DB) error {
+		version, err := migration.Version(db, cfg.Database.Type)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), version)
+		return nil
+	}),
+}
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create NAME",
+	Short: "Create a new timestamped migration in the source tree",
+	Long: `Create a new timestamped migration. A SQL migration gets one file per
+dialect, all sharing the same version; a Go migration is a single file
+registered with goose that runs for every dialect.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := migration.Create(migrationDir, args[0], migrationType, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			fmt.Fprintln(cmd.OutOrStdout(), "created", file)
+		}
+		return nil
+	},
+}
+
+var migrateFixCmd = &cobra.Command{
+	Use:   "fix",
+	Short: "Renumber timestamped migrations in the source tree sequentially",
+	Long: `Renumber the timestamped migrations in the source tree to follow the
+latest sequential one, in timestamp order. Run it before merging a branch
+that adds migrations, so they cannot end up older than a migration that has
+already been applied.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files, err := migration.Fix(migrationDir)
+		for _, file := range files {
+			fmt.Fprintln(cmd.OutOrStdout(), "renamed", file)
+		}
+		return err
+	},
+}
+
+func init() {
+	migrateCreateCmd.Flags().StringVar(&migrationDir, "dir", "internal/migration/schema", "schema directory in the source tree")
+	migrateCreateCmd.Flags().StringVar(&migrationType, "type", "sql", "migration type: sql or go")
+	migrateFixCmd.Flags().StringVar(&migrationDir, "dir", "internal/migration/schema", "schema directory in the source tree")
+
+	migrateCmd.AddCommand(
+		migrateUpCmd,
+		migrateUpToCmd,
+		migrateDownCmd,
+		migrateDownToCmd,
+		migrateRedoCmd,
+		migrateStatusCmd,
+		migrateVersionCmd,
+		migrateCreateCmd,
+		migrateFixCmd,
+	)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+// withDatabase loads the configuration, connects to the database and runs
+// fn, closing the connection afterwards.
+func withDatabase(fn func(cmd *cobra.Command, cfg *config.Config, db *gorm.DB) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		db, err := database.NewDatabase(&cfg.Database)
+		if err != nil {
+			return fmt.Errorf("failed to connect to database: %w", err)
+		}
+		defer database.Close(db)
+
+		return fn(cmd, cfg, db)
+	}
+}
+
+func parseVersion(arg string) (int64, error) {
+	version, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || version < 0 {
+		return 0, fmt.Errorf("invalid version %q", arg)
+	}
+	return version, nil
+}
@@ -1,19 +1,32 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/shuv1824/go-api-starter/internal/common/auth"
+	"github.com/shuv1824/go-api-starter/internal/common/emailaddr"
+	"github.com/shuv1824/go-api-starter/internal/common/idempotency"
+	"github.com/shuv1824/go-api-starter/internal/common/metrics"
 	"github.com/shuv1824/go-api-starter/internal/common/middleware"
+	"github.com/shuv1824/go-api-starter/internal/common/ratelimit"
+	"github.com/shuv1824/go-api-starter/internal/common/tx"
 	"github.com/shuv1824/go-api-starter/internal/config"
 	userHandlers "github.com/shuv1824/go-api-starter/internal/domains/user/handlers"
 	userDomain "github.com/shuv1824/go-api-starter/internal/domains/user/infra"
+	"github.com/shuv1824/go-api-starter/internal/health"
 	"github.com/shuv1824/go-api-starter/internal/migration"
+	"github.com/shuv1824/go-api-starter/internal/server"
+	"github.com/shuv1824/go-api-starter/internal/telemetry"
 	"github.com/shuv1824/go-api-starter/pkg/database"
 	"github.com/spf13/cobra"
 )
@@ -23,6 +36,9 @@ var rootCmd = &cobra.Command{
 	Short: "A Gin-based REST API with JWT authentication",
 	Long:  `A production-ready REST API template built with Gin, JWT authentication, GORM, and PostgreSQL.`,
 	Run:   rootRun,
+
+	SilenceErrors: true,
+	SilenceUsage:  true,
 }
 
 func Execute() {
@@ -33,42 +49,130 @@ func Execute() {
 }
 
 func rootRun(cmd *cobra.Command, args []string) {
-	cfg, err := config.InitConfig("./config.yaml")
+	opts, err := loadOptions(cmd)
+	if err != nil {
+		log.Fatalf("failed to initialize config: %v\n", err)
+	}
+
+	cfg, err := config.Load(opts)
 	if err != nil {
 		log.Fatalf("failed to initialize config: %v\n", err)
 	}
+	manager := config.NewManager(cfg, opts)
 
 	gin.SetMode(string(cfg.Mode))
 
-	// level := slog.LevelInfo
-	// if cfg.Mode == config.ModeTypeDebug {
-	// 	level = slog.LevelDebug
-	// }
-	//
-	// logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-	// 	Level: level,
-	// }))
+	var logLevel slog.LevelVar
+	slog.SetDefault(newLogger(os.Stdout, cfg.Log, &logLevel))
+	config.Subscribe(manager, func(c *config.Config) slog.Level { return c.Log.SlogLevel() }, logLevel.Set)
+
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), cfg.Tracing)
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %v\n", err)
+	}
 
 	db, err := database.NewDatabase(&cfg.Database)
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v\n", err)
 	}
 
-	err = migration.MigrateUp(db, cfg.Database.Type)
-	if err != nil {
-		log.Fatalf("database migration error: %v\n", err)
+	if sqlLogger, ok := db.Logger.(*database.Logger); ok {
+		config.Subscribe(manager, func(c *config.Config) string { return c.Database.Log.Level }, sqlLogger.SetLevel)
+		config.Subscribe(manager, func(c *config.Config) time.Duration { return c.Database.Log.SlowThreshold }, sqlLogger.SetSlowThreshold)
+	}
+
+	if cfg.Tracing.Enabled {
+		if err := db.Use(telemetry.NewGormPlugin()); err != nil {
+			log.Fatalf("failed to instrument database: %v\n", err)
+		}
+	}
+
+	if cfg.Migration.AutoMigrate {
+		err = migration.MigrateUp(db, cfg.Database.Type, cfg.Migration.LockTimeout)
+		if err != nil {
+			log.Fatalf("database migration error: %v\n", err)
+		}
+	} else {
+		slog.Info("automatic migration disabled, run the migrate command to update the schema")
 	}
 
 	// Initialize services
-	jwtService := auth.NewService(cfg.Secret, time.Hour, time.Hour*24)
+	jwtService := auth.NewService(cfg.Secret.Value(), time.Hour, time.Hour*24)
 	userRepo := userDomain.NewRepository(db)
-	userService := userDomain.NewService(userRepo, jwtService)
+	userSearcher, err := userDomain.NewSearcher(db, cfg.Database.Type)
+	if err != nil {
+		log.Fatalf("user search error: %v\n", err)
+	}
+	txManager := tx.NewManager(db, cfg.Database.TxRetry)
+	emails := emailaddr.Normalizer{LowercaseLocalPart: cfg.Users.LowercaseEmailLocalPart}
+	userService := userDomain.NewTracedService(userDomain.NewService(userRepo, userSearcher, txManager, jwtService, emails))
 	userHandlers := userHandlers.NewHandler(userService)
 
+	var limiterStore ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "sql":
+		limiterStore = ratelimit.NewSQLStore(db, time.Minute)
+	default:
+		limiterStore = ratelimit.NewMemoryStore(32, time.Minute)
+	}
+	limiter := ratelimit.NewLimiter(limiterStore)
+
+	// Rules are read from the manager on every request so limits can be
+	// tuned by reloading the configuration.
+	rateLimit := func(name string, selector func(config.RateLimitConfig) config.RateLimitRule) gin.HandlerFunc {
+		rule := func() ratelimit.Rule {
+			current := manager.Current().RateLimit
+			if !current.Enabled {
+				return ratelimit.Rule{}
+			}
+			rule := selector(current)
+			return ratelimit.Rule{Limit: rule.Requests, Window: rule.Window}
+		}
+		keyFunc := func(c *gin.Context) string {
+			return middleware.RateLimitKeyFuncFor(selector(manager.Current().RateLimit).Key)(c)
+		}
+		return middleware.RateLimitMiddleware(limiter, name, rule, keyFunc)
+	}
+
+	var idempotencyStore idempotency.Store
+	switch cfg.Idempotency.Store {
+	case "sql":
+		idempotencyStore = idempotency.NewSQLStore(db, time.Minute)
+	default:
+		idempotencyStore = idempotency.NewMemoryStore(time.Minute)
+	}
+	idempotencyStore, err = idempotency.NewSealedStore(idempotencyStore, cfg.Secret.Value())
+	if err != nil {
+		log.Fatalf("idempotency store error: %v\n", err)
+	}
+
+	idempotent := func(c *gin.Context) { c.Next() }
+	if cfg.Idempotency.Enabled {
+		idempotent = middleware.IdempotencyMiddleware(idempotencyStore, cfg.Idempotency.TTL, cfg.Idempotency.Lease)
+	}
+
+	checker := health.NewChecker(cfg.Health.CacheTTL, cfg.Health.CheckTimeout)
+	checker.AddReadinessCheck("database", health.DatabaseCheck(db), 0)
+	migrationCheck, err := health.MigrationCheck(db, cfg.Database.Type)
+	if err != nil {
+		log.Fatalf("migration check error: %v\n", err)
+	}
+	checker.AddReadinessCheck("migrations", migrationCheck, 0)
+
 	router := gin.Default()
+	// The client IP keys rate limits, so forwarded headers are only
+	// believed from the configured proxies.
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("trusted proxies error: %v\n", err)
+	}
 
 	// Add middleware
-	router.Use(middleware.CORSMiddleware())
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(middleware.TracingMiddleware())
+	if cfg.Metrics.Enabled {
+		router.Use(middleware.MetricsMiddleware())
+	}
+	router.Use(middleware.CORSMiddleware(func() config.CORSConfig { return manager.Current().CORS }))
 	router.Use(middleware.LoggingMiddleware())
 	router.Use(gin.Recovery())
 
@@ -78,10 +182,43 @@ func rootRun(cmd *cobra.Command, args []string) {
 			"message": "pong",
 		})
 	})
+	router.GET("/livez", checker.LivezHandler)
+	router.GET("/readyz", checker.ReadyzHandler)
+
+	// Check details are served on the admin listener when there is one, and
+	// to staff otherwise.
+	var adminMux *http.ServeMux
+	if cfg.Metrics.Enabled && cfg.Metrics.Addr != "" {
+		adminMux = http.NewServeMux()
+		adminMux.Handle("/health", checker.DetailsHandler())
+	}
+
+	var adminSrv *server.Server
+	if cfg.Metrics.Enabled {
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatalf("failed to get database instance: %v\n", err)
+		}
+		if err := metrics.RegisterDBStats(sqlDB, cfg.Database.DbName); err != nil {
+			log.Fatalf("failed to register database metrics: %v\n", err)
+		}
+
+		if cfg.Metrics.Addr == "" {
+			router.GET(cfg.Metrics.Path, gin.WrapH(metrics.Handler()))
+		} else {
+			adminMux.Handle(cfg.Metrics.Path, metrics.Handler())
+			adminSrv = server.New(cfg.Server, cfg.Metrics.Addr, adminMux)
+		}
+	}
+
+	// Probes are registered above so they are not rate limited
+	router.Use(rateLimit("global", func(c config.RateLimitConfig) config.RateLimitRule { return c.Global }))
 
 	// Public routes
 	auth := router.Group("/api/v1/auth")
 	{
+		auth.Use(rateLimit("auth", func(c config.RateLimitConfig) config.RateLimitRule { return c.Auth }))
+		auth.Use(idempotent)
 		auth.POST("/register", userHandlers.Register)
 		auth.POST("/login", userHandlers.Login)
 	}
@@ -90,11 +227,53 @@ func rootRun(cmd *cobra.Command, args []string) {
 	authenticated := router.Group("/api/v1")
 	{
 		authenticated.Use(middleware.AuthMiddleware(jwtService))
+		authenticated.Use(rateLimit("api", func(c config.RateLimitConfig) config.RateLimitRule { return c.API }))
+		authenticated.Use(middleware.RequireIfMatch())
+		authenticated.Use(idempotent)
 		authenticated.GET("/profile", userHandlers.GetProfile)
+		authenticated.PATCH("/profile", userHandlers.UpdateProfile)
+		authenticated.DELETE("/profile", userHandlers.DeleteProfile)
+		authenticated.GET("/users", userHandlers.RequireStaff, userHandlers.ListUsers)
+		authenticated.GET("/users/search", userHandlers.RequireStaff, userHandlers.SearchUsers)
+		if adminMux == nil {
+			authenticated.GET("/health", userHandlers.RequireStaff, gin.WrapH(checker.DetailsHandler()))
+		}
 	}
 
-	err = router.Run(fmt.Sprintf(":%d", cfg.Port))
-	if err != nil {
-		log.Fatalf("error starting api: %v\n", err)
+	srv := server.New(cfg.Server, fmt.Sprintf(":%d", cfg.Port), router)
+	srv.OnDrain(func() { checker.SetReady(false) })
+
+	srv.OnShutdown("telemetry", shutdownTracing)
+
+	srv.OnShutdown("database", func(ctx context.Context) error { return database.Close(db) })
+	for name, store := range map[string]any{
+		"rate limit store":  limiterStore,
+		"idempotency store": idempotencyStore,
+	} {
+		if closer, ok := store.(io.Closer); ok {
+			srv.OnShutdown(name, func(ctx context.Context) error { return closer.Close() })
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := manager.Watch(ctx); err != nil {
+			slog.Error("config reload disabled", "error", err)
+		}
+	}()
+
+	if adminSrv != nil {
+		go func() {
+			if err := adminSrv.Run(ctx); err != nil {
+				slog.Error("admin server stopped", "error", err)
+			}
+		}()
+	}
+
+	checker.SetReady(true)
+	if err := srv.Run(ctx); err != nil {
+		log.Fatalf("error running api: %v\n", err)
 	}
 }
@@ -18,7 +18,7 @@ func setupTestAuthService(t *testing.T) *Service {
 		// Fallback to default values if config not available
 		return NewService("test-secret-key", time.Hour, time.Hour*24)
 	}
-	return NewService(cfg.Secret, time.Hour, time.Hour*24)
+	return NewService(cfg.Secret.Value(), time.Hour, time.Hour*24)
 }
 
 func TestMain(m *testing.M) {
@@ -178,7 +178,7 @@ func TestService_ValidateToken_TokenExpiration(t *testing.T) {
 	cfg, err := config.InitConfig("../../../config.test.yaml")
 	secret := "test-secret"
 	if err == nil {
-		secret = cfg.Secret
+		secret = cfg.Secret.Value()
 	}
 	shortDurationService := NewService(secret, 10*time.Millisecond, time.Hour)
 	
@@ -204,7 +204,7 @@ func TestService_TokenDuration(t *testing.T) {
 	cfg, err := config.InitConfig("../../../config.test.yaml")
 	secret := "test-secret"
 	if err == nil {
-		secret = cfg.Secret
+		secret = cfg.Secret.Value()
 	}
 	service := NewService(secret, tokenDuration, time.Hour*24)
 	
@@ -0,0 +1,40 @@
+// Package emailaddr normalizes email addresses so that the ways of writing
+// one address map to the same account.
+package emailaddr
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizer brings addresses to the form they are stored and looked up in.
+type Normalizer struct {
+	// LowercaseLocalPart also lowercases the part before the @. RFC 5321
+	// lets mail servers treat it case-sensitively, so it is kept as typed by
+	// default; lookups and the unique index ignore its case either way.
+	LowercaseLocalPart bool
+}
+
+// Normalize trims surrounding whitespace, composes the address to Unicode
+// NFC and lowercases the domain. Addresses without an @ are only trimmed and
+// composed; validation rejects them elsewhere.
+func (n Normalizer) Normalize(address string) string {
+	address = norm.NFC.String(strings.TrimSpace(address))
+
+	at := strings.LastIndexByte(address, '@')
+	if at < 0 {
+		return address
+	}
+	local, domain := address[:at], address[at+1:]
+	if n.LowercaseLocalPart {
+		local = strings.ToLower(local)
+	}
+	return local + "@" + strings.ToLower(domain)
+}
+
+// Key identifies the account an address belongs to: two addresses with the
+// same key may not both be registered.
+func Key(address string) string {
+	return strings.ToLower(Normalizer{}.Normalize(address))
+}
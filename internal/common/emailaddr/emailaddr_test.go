@@ -0,0 +1,41 @@
+package emailaddr
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name           string
+		address        string
+		lowercaseLocal bool
+		want           string
+	}{
+		{"already normalized", "foo@example.com", false, "foo@example.com"},
+		{"surrounding whitespace", "  foo@example.com\t", false, "foo@example.com"},
+		{"domain case", "Foo@Example.COM", false, "Foo@example.com"},
+		{"local part case", "Foo@Example.COM", true, "foo@example.com"},
+		{"decomposed accent", "jose\u0301@example.com", false, "jos\u00e9@example.com"},
+		{"quoted local part with @", `"a@b"@Example.com`, false, `"a@b"@example.com`},
+		{"no @", " Foo ", true, "Foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := Normalizer{LowercaseLocalPart: tt.lowercaseLocal}
+			if got := n.Normalize(tt.address); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	same := []string{"Foo@Example.com", "foo@example.com", " FOO@EXAMPLE.COM "}
+	for _, address := range same {
+		if got := Key(address); got != "foo@example.com" {
+			t.Errorf("%q: expected key foo@example.com, got %q", address, got)
+		}
+	}
+	if Key("jose\u0301@example.com") != Key("JOS\u00c9@example.com") {
+		t.Error("expected decomposed and composed accents to share a key")
+	}
+}
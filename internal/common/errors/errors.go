@@ -12,6 +12,7 @@ var (
 	ErrForbidden       = errors.New("forbidden")
 	ErrInternalServer  = errors.New("internal server error")
 	ErrEmailExists     = errors.New("email already exists")
+	ErrVersionConflict = errors.New("resource was modified concurrently")
 	ErrInvalidPassword = errors.New("invalid password")
 	ErrTokenExpired    = errors.New("token expired")
 	ErrInvalidToken    = errors.New("invalid token")
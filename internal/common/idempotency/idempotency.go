@@ -0,0 +1,35 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Record is what is kept for an Idempotency-Key. A record that is not yet
+// Completed acts as a lock held by the request currently executing until
+// its lease runs out.
+type Record struct {
+	Fingerprint string
+	Completed   bool
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	ExpiresAt   time.Time
+}
+
+// Store persists idempotency records. Implementations must be safe for
+// concurrent use and make Begin atomic so only one request wins a key.
+type Store interface {
+	// Begin reserves key for lease for a request with the given
+	// fingerprint. When the key is already taken the existing record is
+	// returned and acquired is false.
+	Begin(ctx context.Context, key, fingerprint string, lease time.Duration) (existing *Record, acquired bool, err error)
+
+	// Complete stores the final response for a key reserved by Begin, kept
+	// until record.ExpiresAt.
+	Complete(ctx context.Context, key string, record *Record) error
+
+	// Release drops a reservation so that the request may be retried.
+	Release(ctx context.Context, key string) error
+}
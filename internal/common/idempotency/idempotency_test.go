@@ -0,0 +1,172 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/internal/migration"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+)
+
+func newTestSQLStore(t *testing.T) *SQLStore {
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = ":memory:"
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	if err := migration.MigrateUp(db, cfg.Type, 0); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return NewSQLStore(db, 0)
+}
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"memory": func(t *testing.T) Store { return NewMemoryStore(0) },
+		"sql":    func(t *testing.T) Store { return newTestSQLStore(t) },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			testStore(t, newStore(t))
+		})
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	if _, acquired, err := store.Begin(ctx, "key", "print", time.Hour); err != nil || !acquired {
+		t.Fatalf("expected a new key to be acquired, got %v, %v", acquired, err)
+	}
+	existing, acquired, err := store.Begin(ctx, "key", "other", time.Hour)
+	if err != nil || acquired {
+		t.Fatalf("expected a reserved key to be taken, got %v, %v", acquired, err)
+	}
+	if existing.Fingerprint != "print" || existing.Completed {
+		t.Errorf("expected the pending reservation, got %+v", existing)
+	}
+
+	err = store.Complete(ctx, "key", &Record{
+		Fingerprint: "print",
+		StatusCode:  http.StatusCreated,
+		Header:      http.Header{"X-Call": {"1"}},
+		Body:        []byte(`{"id":1}`),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+	existing, acquired, err = store.Begin(ctx, "key", "print", time.Hour)
+	if err != nil || acquired {
+		t.Fatalf("expected a completed key to be taken, got %v, %v", acquired, err)
+	}
+	if !existing.Completed || existing.StatusCode != http.StatusCreated || existing.Header.Get("X-Call") != "1" || string(existing.Body) != `{"id":1}` {
+		t.Errorf("expected the stored response, got %+v", existing)
+	}
+
+	if err := store.Release(ctx, "key"); err != nil {
+		t.Fatalf("failed to release: %v", err)
+	}
+	if _, acquired, err := store.Begin(ctx, "key", "print", time.Hour); err != nil || !acquired {
+		t.Errorf("expected a released key to be acquired again, got %v, %v", acquired, err)
+	}
+
+	if _, acquired, err := store.Begin(ctx, "expired", "print", -time.Second); err != nil || !acquired {
+		t.Fatalf("expected a new key to be acquired, got %v, %v", acquired, err)
+	}
+	if _, acquired, err := store.Begin(ctx, "expired", "other", time.Hour); err != nil || !acquired {
+		t.Errorf("expected an expired key to be acquired again, got %v, %v", acquired, err)
+	}
+
+	// A completed response outlives the lease of its reservation.
+	store.Begin(ctx, "leased", "print", -time.Second)
+	err = store.Complete(ctx, "leased", &Record{Fingerprint: "print", StatusCode: http.StatusOK, ExpiresAt: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+	if existing, acquired, err := store.Begin(ctx, "leased", "print", time.Hour); err != nil || acquired || !existing.Completed {
+		t.Errorf("expected the completed response to be kept for its ttl, got %+v, %v, %v", existing, acquired, err)
+	}
+}
+
+func TestSQLStore_Cleanup(t *testing.T) {
+	store := newTestSQLStore(t)
+	ctx := context.Background()
+
+	store.Begin(ctx, "old", "print", time.Minute)
+	store.Begin(ctx, "new", "print", time.Hour)
+	if err := store.Cleanup(ctx, time.Now().Add(30*time.Minute)); err != nil {
+		t.Fatalf("failed to clean up: %v", err)
+	}
+
+	var keys []string
+	if err := store.db.Model(&sqlRecord{}).Pluck("idempotency_key", &keys).Error; err != nil {
+		t.Fatalf("failed to list keys: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "new" {
+		t.Errorf("expected only the unexpired key to remain, got %v", keys)
+	}
+}
+
+func TestMemoryStore_Cleanup(t *testing.T) {
+	store := NewMemoryStore(0)
+	ctx := context.Background()
+
+	store.Begin(ctx, "old", "print", time.Minute)
+	store.Begin(ctx, "new", "print", time.Hour)
+	store.Cleanup(time.Now().Add(30 * time.Minute))
+
+	if _, ok := store.records["old"]; ok {
+		t.Error("expected the expired key to be removed")
+	}
+	if _, ok := store.records["new"]; !ok {
+		t.Error("expected the unexpired key to remain")
+	}
+}
+
+func TestSealedStore(t *testing.T) {
+	inner := NewMemoryStore(0)
+	store, err := NewSealedStore(inner, "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	testStore(t, store)
+
+	ctx := context.Background()
+	body := []byte(`{"token":"secret-jwt"}`)
+	store.Begin(ctx, "login", "print", time.Hour)
+	if err := store.Complete(ctx, "login", &Record{Fingerprint: "print", StatusCode: http.StatusOK, Body: body, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("failed to complete: %v", err)
+	}
+
+	if stored := inner.records["login"].Body; bytes.Contains(stored, []byte("secret-jwt")) {
+		t.Errorf("expected the stored body to be encrypted, got %q", stored)
+	}
+	existing, _, err := store.Begin(ctx, "login", "print", time.Hour)
+	if err != nil || !bytes.Equal(existing.Body, body) {
+		t.Errorf("expected the decrypted body, got %q, %v", existing.Body, err)
+	}
+
+	// A body is bound to its key. One that cannot be decrypted, like one
+	// sealed with a rotated secret, is dropped and the key reserved again.
+	inner.records["moved"] = inner.records["login"]
+	if _, acquired, err := store.Begin(ctx, "moved", "print", time.Hour); err != nil || !acquired {
+		t.Errorf("expected a body stored under another key to be dropped, got %v, %v", acquired, err)
+	}
+
+	rotated, err := NewSealedStore(inner, "fedcba9876543210fedcba9876543210")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, acquired, err := rotated.Begin(ctx, "login", "print", time.Hour); err != nil || !acquired {
+		t.Errorf("expected a body sealed with another secret to be dropped, got %v, %v", acquired, err)
+	}
+}
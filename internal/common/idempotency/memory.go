@@ -0,0 +1,104 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps records in process memory. It is only suitable for
+// single instance deployments.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	now     func() time.Time
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// NewMemoryStore creates a store and starts a janitor that drops expired
+// records every cleanupInterval.
+func NewMemoryStore(cleanupInterval time.Duration) *MemoryStore {
+	s := &MemoryStore{
+		records: make(map[string]*Record),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+
+	if cleanupInterval > 0 {
+		go s.janitor(cleanupInterval)
+	}
+
+	return s
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, key, fingerprint string, lease time.Duration) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if record, ok := s.records[key]; ok && now.Before(record.ExpiresAt) {
+		copied := *record
+		return &copied, false, nil
+	}
+
+	s.records[key] = &Record{
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(lease),
+	}
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, key string, record *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[key]; !ok {
+		return nil
+	}
+
+	copied := *record
+	copied.Completed = true
+	s.records[key] = &copied
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// Cleanup removes expired records.
+func (s *MemoryStore) Cleanup(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+}
+
+// Close stops the janitor goroutine.
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *MemoryStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Cleanup(s.now())
+		case <-s.stop:
+			return
+		}
+	}
+}
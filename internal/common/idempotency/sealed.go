@@ -0,0 +1,106 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// SealedStore encrypts response bodies with AES-GCM before passing records
+// to the underlying store, so tokens in stored responses, such as the JWT
+// returned on register, cannot be read from the store.
+type SealedStore struct {
+	store Store
+	aead  cipher.AEAD
+}
+
+// NewSealedStore wraps store, deriving the encryption key from secret.
+func NewSealedStore(store Store, secret string) (*SealedStore, error) {
+	key := sha256.Sum256([]byte("idempotency:" + secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SealedStore{store: store, aead: aead}, nil
+}
+
+// Begin treats a stored response that cannot be decrypted, for example one
+// sealed before the secret was rotated, as missing: it is dropped and the key
+// reserved again.
+func (s *SealedStore) Begin(ctx context.Context, key, fingerprint string, lease time.Duration) (*Record, bool, error) {
+	existing, acquired, err := s.store.Begin(ctx, key, fingerprint, lease)
+	if err != nil || existing == nil || !existing.Completed {
+		return existing, acquired, err
+	}
+
+	body, err := s.open(key, existing.Body)
+	if err == nil {
+		existing.Body = body
+		return existing, acquired, nil
+	}
+
+	slog.WarnContext(ctx, "dropping idempotent response that cannot be decrypted", "error", err)
+	if err := s.store.Release(ctx, key); err != nil {
+		return nil, false, err
+	}
+	existing, acquired, err = s.store.Begin(ctx, key, fingerprint, lease)
+	if err != nil || existing == nil || !existing.Completed {
+		return existing, acquired, err
+	}
+	// Another request completed the key in the meantime.
+	if existing.Body, err = s.open(key, existing.Body); err != nil {
+		return nil, false, fmt.Errorf("failed to decrypt idempotent response: %w", err)
+	}
+	return existing, acquired, nil
+}
+
+func (s *SealedStore) Complete(ctx context.Context, key string, record *Record) error {
+	sealed := *record
+	body, err := s.seal(key, record.Body)
+	if err != nil {
+		return err
+	}
+	sealed.Body = body
+	return s.store.Complete(ctx, key, &sealed)
+}
+
+func (s *SealedStore) Release(ctx context.Context, key string) error {
+	return s.store.Release(ctx, key)
+}
+
+// Close closes the underlying store when it can be closed.
+func (s *SealedStore) Close() error {
+	if closer, ok := s.store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// seal prepends a random nonce to the ciphertext. The key is authenticated
+// along with the body, so a body cannot be replayed under another key.
+func (s *SealedStore) seal(key string, body []byte) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, body, []byte(key)), nil
+}
+
+func (s *SealedStore) open(key string, sealed []byte) ([]byte, error) {
+	if len(sealed) < s.aead.NonceSize() {
+		return nil, errors.New("sealed body is too short")
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	return s.aead.Open(nil, nonce, ciphertext, []byte(key))
+}
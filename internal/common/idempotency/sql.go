@@ -0,0 +1,148 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sqlRecord struct {
+	IdempotencyKey string `gorm:"primaryKey;size:255"`
+	Fingerprint    string `gorm:"size:64;not null"`
+	Completed      bool   `gorm:"not null"`
+	StatusCode     int    `gorm:"not null"`
+	Header         string `gorm:"not null"`
+	Body           []byte
+	ExpiresAt      time.Time `gorm:"not null;index"`
+}
+
+func (sqlRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+// SQLStore keeps records in the idempotency_keys table. The primary key on
+// the idempotency key makes Begin a lock shared by all API instances.
+type SQLStore struct {
+	db   *gorm.DB
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewSQLStore creates a store backed by db and starts a janitor that deletes
+// expired rows every cleanupInterval.
+func NewSQLStore(db *gorm.DB, cleanupInterval time.Duration) *SQLStore {
+	s := &SQLStore{
+		db:   db,
+		stop: make(chan struct{}),
+	}
+
+	if cleanupInterval > 0 {
+		go s.janitor(cleanupInterval)
+	}
+
+	return s
+}
+
+func (s *SQLStore) Begin(ctx context.Context, key, fingerprint string, lease time.Duration) (*Record, bool, error) {
+	db := s.db.WithContext(ctx)
+	now := time.Now()
+
+	err := db.Where("idempotency_key = ? AND expires_at <= ?", key, now).Delete(&sqlRecord{}).Error
+	if err != nil {
+		return nil, false, err
+	}
+
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&sqlRecord{
+		IdempotencyKey: key,
+		Fingerprint:    fingerprint,
+		Header:         "{}",
+		ExpiresAt:      now.Add(lease),
+	})
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return nil, true, nil
+	}
+
+	var row sqlRecord
+	if err := db.Where("idempotency_key = ?", key).First(&row).Error; err != nil {
+		return nil, false, err
+	}
+
+	record, err := row.record()
+	if err != nil {
+		return nil, false, err
+	}
+	return record, false, nil
+}
+
+func (s *SQLStore) Complete(ctx context.Context, key string, record *Record) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return err
+	}
+
+	return s.db.WithContext(ctx).Model(&sqlRecord{}).
+		Where("idempotency_key = ?", key).
+		Updates(map[string]any{
+			"completed":   true,
+			"status_code": record.StatusCode,
+			"header":      string(header),
+			"body":        record.Body,
+			"expires_at":  record.ExpiresAt,
+		}).Error
+}
+
+func (s *SQLStore) Release(ctx context.Context, key string) error {
+	return s.db.WithContext(ctx).Where("idempotency_key = ?", key).Delete(&sqlRecord{}).Error
+}
+
+// Cleanup deletes expired rows.
+func (s *SQLStore) Cleanup(ctx context.Context, now time.Time) error {
+	return s.db.WithContext(ctx).Where("expires_at <= ?", now).Delete(&sqlRecord{}).Error
+}
+
+// Close stops the janitor goroutine.
+func (s *SQLStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *SQLStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Cleanup(context.Background(), time.Now()); err != nil {
+				slog.Error("failed to clean up idempotency keys", "error", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (r sqlRecord) record() (*Record, error) {
+	header := http.Header{}
+	if err := json.Unmarshal([]byte(r.Header), &header); err != nil {
+		return nil, err
+	}
+
+	return &Record{
+		Fingerprint: r.Fingerprint,
+		Completed:   r.Completed,
+		StatusCode:  r.StatusCode,
+		Header:      header,
+		Body:        r.Body,
+		ExpiresAt:   r.ExpiresAt,
+	}, nil
+}
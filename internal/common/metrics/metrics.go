@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "api"
+
+// Outcomes used as label values. Keeping them as constants keeps the label
+// cardinality bounded.
+const (
+	OutcomeSuccess            = "success"
+	OutcomeError              = "error"
+	OutcomeConflict           = "conflict"
+	OutcomeInvalidCredentials = "invalid_credentials"
+	OutcomeInactive           = "inactive"
+	OutcomeValid              = "valid"
+	OutcomeMissing            = "missing"
+	OutcomeMalformed          = "malformed"
+	OutcomeExpired            = "expired"
+	OutcomeInvalid            = "invalid"
+)
+
+var registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "Number of HTTP requests currently being served.",
+	})
+
+	registrations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "user_registrations_total",
+		Help:      "Number of user registrations by outcome.",
+	}, []string{"outcome"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "user_logins_total",
+		Help:      "Number of login attempts by outcome.",
+	}, []string{"outcome"})
+
+	tokenValidations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_token_validations_total",
+		Help:      "Number of access token validations by outcome.",
+	}, []string{"outcome"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		httpInFlight,
+		registrations,
+		logins,
+		tokenValidations,
+	)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// RegisterDBStats exposes the sql.DBStats of the connection pool as gauges.
+func RegisterDBStats(db *sql.DB, dbName string) error {
+	return registry.Register(collectors.NewDBStatsCollector(db, dbName))
+}
+
+// HTTPRequestStarted tracks a request as in flight and returns a function
+// recording it once it has completed. Route must be a template such as
+// /users/:id and not the raw path.
+func HTTPRequestStarted() func(route, method string, status int) {
+	start := time.Now()
+	httpInFlight.Inc()
+
+	return func(route, method string, status int) {
+		httpInFlight.Dec()
+		method = normalizeMethod(method)
+		httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+		httpDuration.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+	}
+}
+
+func RecordRegistration(outcome string) {
+	registrations.WithLabelValues(outcome).Inc()
+}
+
+func RecordLogin(outcome string) {
+	logins.WithLabelValues(outcome).Inc()
+}
+
+func RecordTokenValidation(outcome string) {
+	tokenValidations.WithLabelValues(outcome).Inc()
+}
+
+// normalizeMethod folds unknown methods into one label value so clients
+// cannot create series by sending arbitrary verbs.
+func normalizeMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return method
+	default:
+		return "OTHER"
+	}
+}
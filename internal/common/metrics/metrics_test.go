@@ -0,0 +1,121 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// The collectors are package globals shared by every test in the package and
+// every run of -count, so tests compare values before and after recording
+// instead of asserting absolute ones.
+
+func scrape(t *testing.T) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func histogramCount(t *testing.T, observer prometheus.Observer) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := observer.(prometheus.Metric).Write(&metric); err != nil {
+		t.Fatalf("failed to read histogram: %v", err)
+	}
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestHTTPRequestStarted(t *testing.T) {
+	okRequests := httpRequests.WithLabelValues("/api/v1/profile", http.MethodGet, "200")
+	otherRequests := httpRequests.WithLabelValues("/api/v1/profile", "OTHER", "404")
+	duration := httpDuration.WithLabelValues("/api/v1/profile", http.MethodGet)
+
+	okBefore, otherBefore := testutil.ToFloat64(okRequests), testutil.ToFloat64(otherRequests)
+	durationBefore := histogramCount(t, duration)
+	inFlightBefore := testutil.ToFloat64(httpInFlight)
+
+	done := HTTPRequestStarted()
+	if got := testutil.ToFloat64(httpInFlight) - inFlightBefore; got != 1 {
+		t.Errorf("expected 1 more request in flight, got %v", got)
+	}
+	done("/api/v1/profile", http.MethodGet, http.StatusOK)
+
+	done = HTTPRequestStarted()
+	done("/api/v1/profile", "PROPFIND", http.StatusNotFound)
+
+	if got := testutil.ToFloat64(okRequests) - okBefore; got != 1 {
+		t.Errorf("expected 1 more GET 200 request, got %v", got)
+	}
+	if got := testutil.ToFloat64(otherRequests) - otherBefore; got != 1 {
+		t.Errorf("expected unknown method counted as OTHER, got %v", got)
+	}
+	if got := histogramCount(t, duration) - durationBefore; got != 1 {
+		t.Errorf("expected 1 more duration observation, got %v", got)
+	}
+	if got := testutil.ToFloat64(httpInFlight) - inFlightBefore; got != 0 {
+		t.Errorf("expected no request left in flight, got %v", got)
+	}
+
+	body := scrape(t)
+	for _, want := range []string{
+		`api_http_requests_total{method="GET",route="/api/v1/profile",status="200"}`,
+		`api_http_request_duration_seconds_count{method="GET",route="/api/v1/profile"}`,
+		`api_http_requests_in_flight`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected metrics output to contain %q", want)
+		}
+	}
+}
+
+func TestDomainCounters(t *testing.T) {
+	tests := []struct {
+		name    string
+		counter prometheus.Counter
+		record  func()
+		series  string
+	}{
+		{
+			name:    "registration",
+			counter: registrations.WithLabelValues(OutcomeConflict),
+			record:  func() { RecordRegistration(OutcomeConflict) },
+			series:  `api_user_registrations_total{outcome="conflict"}`,
+		},
+		{
+			name:    "login",
+			counter: logins.WithLabelValues(OutcomeInvalidCredentials),
+			record:  func() { RecordLogin(OutcomeInvalidCredentials) },
+			series:  `api_user_logins_total{outcome="invalid_credentials"}`,
+		},
+		{
+			name:    "token validation",
+			counter: tokenValidations.WithLabelValues(OutcomeExpired),
+			record:  func() { RecordTokenValidation(OutcomeExpired) },
+			series:  `api_auth_token_validations_total{outcome="expired"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := testutil.ToFloat64(tt.counter)
+			tt.record()
+			if got := testutil.ToFloat64(tt.counter) - before; got != 1 {
+				t.Errorf("expected counter to grow by 1, got %v", got)
+			}
+			if body := scrape(t); !strings.Contains(body, tt.series) {
+				t.Errorf("expected metrics output to contain %q", tt.series)
+			}
+		})
+	}
+}
@@ -1,17 +1,21 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/shuv1824/go-api-starter/internal/common/auth"
+	"github.com/shuv1824/go-api-starter/internal/common/metrics"
 )
 
 func AuthMiddleware(jwtService *auth.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
+			metrics.RecordTokenValidation(metrics.OutcomeMissing)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
 			c.Abort()
 			return
@@ -19,6 +23,7 @@ func AuthMiddleware(jwtService *auth.Service) gin.HandlerFunc {
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
+			metrics.RecordTokenValidation(metrics.OutcomeMalformed)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
 			c.Abort()
 			return
@@ -26,11 +31,17 @@ func AuthMiddleware(jwtService *auth.Service) gin.HandlerFunc {
 
 		claims, err := jwtService.ValidateToken(tokenString)
 		if err != nil {
+			if errors.Is(err, jwt.ErrTokenExpired) {
+				metrics.RecordTokenValidation(metrics.OutcomeExpired)
+			} else {
+				metrics.RecordTokenValidation(metrics.OutcomeInvalid)
+			}
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
 			c.Abort()
 			return
 		}
 
+		metrics.RecordTokenValidation(metrics.OutcomeValid)
 		c.Set("claims", claims)
 		c.Next()
 	}
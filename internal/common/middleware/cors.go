@@ -1,15 +1,43 @@
 package middleware
 
 import (
+	"slices"
+	"strconv"
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/shuv1824/go-api-starter/internal/config"
 )
 
-func CORSMiddleware() gin.HandlerFunc {
+// CORSMiddleware applies the CORS policy returned by policy on every
+// request, so a reloaded configuration takes effect immediately.
+func CORSMiddleware(policy func() config.CORSConfig) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		cors := policy()
+		header := c.Writer.Header()
+
+		origin := c.GetHeader("Origin")
+		switch {
+		case slices.Contains(cors.AllowedOrigins, "*"):
+			// Browsers refuse credentials for a wildcard, which is why
+			// validation rejects combining them.
+			header.Set("Access-Control-Allow-Origin", "*")
+		case origin != "" && slices.Contains(cors.AllowedOrigins, origin):
+			header.Set("Access-Control-Allow-Origin", origin)
+			header.Add("Vary", "Origin")
+		}
+
+		if cors.AllowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		header.Set("Access-Control-Allow-Headers", strings.Join(cors.AllowedHeaders, ", "))
+		header.Set("Access-Control-Allow-Methods", strings.Join(cors.AllowedMethods, ", "))
+		if len(cors.ExposedHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(cors.ExposedHeaders, ", "))
+		}
+		if cors.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+		}
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuv1824/go-api-starter/internal/common/auth"
+	"github.com/shuv1824/go-api-starter/internal/common/idempotency"
+	"github.com/shuv1824/go-api-starter/internal/common/requestid"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// idempotencyStoreTimeout bounds recording the outcome of a request, which
+// happens even when the client has gone away.
+const idempotencyStoreTimeout = 5 * time.Second
+
+// Headers describing the current request rather than the stored response,
+// which must not be replayed.
+var volatileHeaders = []string{
+	"Date",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"Retry-After",
+	requestid.Header,
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes unsafe requests carrying an Idempotency-Key
+// header safe to retry. The first request executes and its response is
+// stored for ttl; retries with the same payload get the stored response,
+// retries with a different payload are rejected, and retries arriving while
+// the first request is still running, for at most lease, get 409 Conflict.
+// Server errors are not stored so the client can try again.
+func IdempotencyMiddleware(store idempotency.Store, ttl, lease time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || !isUnsafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		scopedKey := scopedIdempotencyKey(c, key)
+		fingerprint := requestFingerprint(c, body)
+
+		existing, acquired, err := store.Begin(ctx, scopedKey, fingerprint, lease)
+		if err != nil {
+			slog.Error("idempotency store unavailable", "error", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Service unavailable"})
+			c.Abort()
+			return
+		}
+
+		if !acquired {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case !existing.Completed:
+				c.Header("Retry-After", "1")
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already in progress"})
+			default:
+				replayResponse(c, existing)
+			}
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// The outcome is recorded even if the client disconnected, or the
+		// key would stay reserved until the lease runs out.
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+		defer cancel()
+
+		completed := false
+		defer func() {
+			if !completed {
+				if err := store.Release(storeCtx, scopedKey); err != nil {
+					slog.Error("failed to release idempotency key", "error", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		header := recorder.Header().Clone()
+		for _, name := range volatileHeaders {
+			header.Del(name)
+		}
+
+		err = store.Complete(storeCtx, scopedKey, &idempotency.Record{
+			Fingerprint: fingerprint,
+			StatusCode:  recorder.Status(),
+			Header:      header,
+			Body:        recorder.body.Bytes(),
+			ExpiresAt:   time.Now().Add(ttl),
+		})
+		if err != nil {
+			slog.Error("failed to store idempotent response", "error", err)
+			return
+		}
+		completed = true
+	}
+}
+
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// idempotencyScope keeps keys of different users and routes apart so that a
+// client cannot replay someone else's response by guessing their key.
+func idempotencyScope(c *gin.Context) string {
+	scope := c.Request.Method + " " + c.FullPath()
+	if claims, ok := c.Get("claims"); ok {
+		if claims, ok := claims.(*auth.Claims); ok {
+			scope += " user:" + claims.UserID
+		}
+	}
+	return scope
+}
+
+// scopedIdempotencyKey hashes the scope and key, so stored keys have a fixed
+// length however long the route, user ID and client key are.
+func scopedIdempotencyKey(c *gin.Context, key string) string {
+	sum := sha256.Sum256([]byte(idempotencyScope(c) + ":" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func requestFingerprint(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, c.Request.Method+"\n"+c.Request.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replayResponse(c *gin.Context, record *idempotency.Record) {
+	header := c.Writer.Header()
+	for name, values := range record.Header {
+		header[name] = values
+	}
+	header.Set("Idempotent-Replayed", "true")
+	c.Status(record.StatusCode)
+	c.Writer.Write(record.Body)
+}
@@ -0,0 +1,229 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuv1824/go-api-starter/internal/common/idempotency"
+	"github.com/shuv1824/go-api-starter/internal/common/requestid"
+)
+
+func setupIdempotentRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(idempotency.NewMemoryStore(0), time.Hour, time.Minute))
+	router.POST("/register", handler)
+	return router
+}
+
+func doRequest(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_Replay(t *testing.T) {
+	var calls int32
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		n := atomic.AddInt32(&calls, 1)
+		c.Header("X-Call", string(rune('0'+n)))
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+
+	first := doRequest(router, "abc", `{"email":"a@example.com"}`)
+	second := doRequest(router, "abc", `{"email":"a@example.com"}`)
+
+	if calls != 1 {
+		t.Fatalf("expected handler to run once, ran %d times", calls)
+	}
+	if second.Code != http.StatusCreated {
+		t.Errorf("expected replayed status 201, got %d", second.Code)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("expected replayed body %q, got %q", first.Body.String(), second.Body.String())
+	}
+	if second.Header().Get("X-Call") != "1" {
+		t.Errorf("expected replayed header, got %q", second.Header().Get("X-Call"))
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("expected Idempotent-Replayed header on replay")
+	}
+}
+
+func TestIdempotencyMiddleware_RequestIDNotReplayed(t *testing.T) {
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		c.Header(requestid.Header, "first-request")
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	doRequest(router, "abc", `{}`)
+	if got := doRequest(router, "abc", `{}`).Header().Get(requestid.Header); got == "first-request" {
+		t.Errorf("expected the original request ID not to be replayed, got %q", got)
+	}
+}
+
+// contextStore fails like a database would once the context is cancelled.
+type contextStore struct {
+	idempotency.Store
+}
+
+func (s contextStore) Complete(ctx context.Context, key string, record *idempotency.Record) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Store.Complete(ctx, key, record)
+}
+
+func (s contextStore) Release(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.Store.Release(ctx, key)
+}
+
+func TestIdempotencyMiddleware_ClientGoneAway(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		want   string
+	}{
+		{name: "response stored", status: http.StatusCreated, want: "true"},
+		{name: "reservation released", status: http.StatusInternalServerError, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			ctx, cancel := context.WithCancel(context.Background())
+			router := gin.New()
+			router.Use(IdempotencyMiddleware(contextStore{idempotency.NewMemoryStore(0)}, time.Hour, time.Minute))
+			router.POST("/register", func(c *gin.Context) {
+				status := tt.status
+				if c.Request.Context() != ctx {
+					status = http.StatusCreated
+				}
+				c.JSON(status, gin.H{})
+				cancel()
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(`{}`)).WithContext(ctx)
+			req.Header.Set("Idempotency-Key", "abc")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			w := doRequest(router, "abc", `{}`)
+			if w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != tt.want {
+				t.Errorf("expected status 201 with Idempotent-Replayed %q after the client went away, got %d %q",
+					tt.want, w.Code, w.Header().Get("Idempotent-Replayed"))
+			}
+		})
+	}
+}
+
+func TestIdempotencyMiddleware_DifferentPayload(t *testing.T) {
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	doRequest(router, "abc", `{"email":"a@example.com"}`)
+	w := doRequest(router, "abc", `{"email":"b@example.com"}`)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		close(started)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- doRequest(router, "abc", `{}`) }()
+	<-started
+
+	w := doRequest(router, "abc", `{}`)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status 409 while first request runs, got %d", w.Code)
+	}
+
+	close(release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("expected first request to succeed, got %d", first.Code)
+	}
+}
+
+func TestIdempotencyMiddleware_ServerErrorNotStored(t *testing.T) {
+	var calls int32
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	doRequest(router, "abc", `{}`)
+	w := doRequest(router, "abc", `{}`)
+
+	if calls != 2 || w.Code != http.StatusCreated {
+		t.Errorf("expected retry after server error to execute, calls=%d status=%d", calls, w.Code)
+	}
+}
+
+func TestIdempotencyMiddleware_NoKey(t *testing.T) {
+	var calls int32
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	doRequest(router, "", `{}`)
+	doRequest(router, "", `{}`)
+
+	if calls != 2 {
+		t.Errorf("expected requests without a key to always execute, ran %d times", calls)
+	}
+}
+
+// keyRecordingStore records the keys the middleware stores records under.
+type keyRecordingStore struct {
+	idempotency.Store
+	keys []string
+}
+
+func (s *keyRecordingStore) Begin(ctx context.Context, key, fingerprint string, lease time.Duration) (*idempotency.Record, bool, error) {
+	s.keys = append(s.keys, key)
+	return s.Store.Begin(ctx, key, fingerprint, lease)
+}
+
+func TestIdempotencyMiddleware_KeyFitsColumn(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &keyRecordingStore{Store: idempotency.NewMemoryStore(0)}
+	router := gin.New()
+	router.Use(IdempotencyMiddleware(store, time.Hour, time.Minute))
+	router.POST("/register", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	if w := doRequest(router, strings.Repeat("k", maxIdempotencyKeyLength), "{}"); w.Code != http.StatusCreated {
+		t.Fatalf("expected the longest key to be accepted, got %d", w.Code)
+	}
+	if w := doRequest(router, strings.Repeat("k", maxIdempotencyKeyLength+1), "{}"); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a longer key to be rejected, got %d", w.Code)
+	}
+	if len(store.keys) != 1 || len(store.keys[0]) != 64 {
+		t.Errorf("expected one key hashed to 64 characters, got %q", store.keys)
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch rejects PATCH, PUT and DELETE requests without an If-Match
+// header, so a client cannot overwrite changes it has not seen by leaving
+// the precondition out.
+func RequireIfMatch() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPatch, http.MethodPut, http.MethodDelete:
+			if c.GetHeader("If-Match") == "" {
+				c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required"})
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
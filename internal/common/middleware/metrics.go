@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuv1824/go-api-starter/internal/common/metrics"
+)
+
+// MetricsMiddleware records request counts and latencies. Requests that do
+// not match a route are grouped under a single label so that scanners
+// probing random paths cannot blow up the number of series.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := metrics.HTTPRequestStarted()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		done(route, c.Request.Method, c.Writer.Status())
+	}
+}
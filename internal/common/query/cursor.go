@@ -0,0 +1,58 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// cursor names the row a page starts after, or ends before when Before is
+// set. It holds the row's sort and key values and the sort it was issued
+// for, so it cannot be replayed against a different order.
+type cursor struct {
+	Sort   string `json:"s"`
+	Value  string `json:"v"`
+	Key    string `json:"k"`
+	Before bool   `json:"b,omitempty"`
+
+	value any
+	key   any
+}
+
+func newCursor(p Params, value, key any, before bool) string {
+	sort := p.Sort
+	if p.Desc {
+		sort = "-" + sort
+	}
+	data, _ := json.Marshal(cursor{Sort: sort, Value: formatValue(value), Key: formatValue(key), Before: before})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(token string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
+
+// parse converts the cursor's values to the types of the sort and key
+// fields, so they compare like the columns they were read from.
+func (c *cursor) parse(field, key Field) error {
+	var err error
+	if c.value, err = field.Parse(c.Value); err != nil {
+		return err
+	}
+	c.key, err = key.Parse(c.Key)
+	return err
+}
+
+func formatValue(v any) string {
+	if t, ok := v.(time.Time); ok {
+		return t.Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(v)
+}
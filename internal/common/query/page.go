@@ -0,0 +1,113 @@
+package query
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Page is the envelope list endpoints respond with. Passing a cursor back
+// as the cursor parameter returns the adjacent page; it is empty when there
+// is no page in that direction.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Find reads the page of T selected by p from db. T is a model or a pointer
+// to one, and must have the fields named by the spec p was parsed with.
+func Find[T any](db *gorm.DB, p Params) (Page[T], error) {
+	backward := p.cursor != nil && p.cursor.Before
+	// Pages before the cursor are read in reverse order, so the limit keeps
+	// the rows closest to it.
+	desc := p.Desc != backward
+
+	for _, condition := range p.Filters {
+		db = condition.apply(db)
+	}
+	column, key := p.field.Column, p.key.Column
+	if p.cursor != nil {
+		op := ">"
+		if desc {
+			op = "<"
+		}
+		db = db.Where(
+			fmt.Sprintf("(%s %s ? OR (%s = ? AND %s %s ?))", column, op, column, key, op),
+			p.cursor.value, p.cursor.value, p.cursor.key,
+		)
+	}
+	db = db.Order(clause.OrderBy{Columns: []clause.OrderByColumn{
+		{Column: clause.Column{Name: column}, Desc: desc},
+		{Column: clause.Column{Name: key}, Desc: desc},
+	}})
+
+	// One extra row tells whether another page follows.
+	items := make([]T, 0, p.Limit+1)
+	result := db.Limit(p.Limit + 1).Find(&items)
+	if result.Error != nil {
+		return Page[T]{}, result.Error
+	}
+	more := len(items) > p.Limit
+	if more {
+		items = items[:p.Limit]
+	}
+	if backward {
+		slices.Reverse(items)
+	}
+
+	page := Page[T]{Items: items}
+	if len(items) == 0 {
+		return page, nil
+	}
+	// Paging forward from a cursor means rows precede the page, and paging
+	// backward means rows follow it.
+	hasNext := more || backward
+	hasPrev := (more && backward) || (p.cursor != nil && !backward)
+
+	sch := result.Statement.Schema
+	sortField, keyField := sch.LookUpField(column), sch.LookUpField(key)
+	if sortField == nil || keyField == nil {
+		return Page[T]{}, fmt.Errorf("query: %s has no column %s or %s", sch.Name, column, key)
+	}
+	cursorAt := func(item T, before bool) string {
+		row := reflect.Indirect(reflect.ValueOf(item))
+		value, _ := sortField.ValueOf(result.Statement.Context, row)
+		keyValue, _ := keyField.ValueOf(result.Statement.Context, row)
+		return newCursor(p, value, keyValue, before)
+	}
+	if hasNext {
+		page.NextCursor = cursorAt(items[len(items)-1], false)
+	}
+	if hasPrev {
+		page.PrevCursor = cursorAt(items[0], true)
+	}
+	return page, nil
+}
+
+func (c Condition) apply(db *gorm.DB) *gorm.DB {
+	column := c.Filter.Field.Column
+	switch c.Filter.Op {
+	case Prefix:
+		return db.Where(column+" LIKE ? ESCAPE '!'", EscapeLike(fmt.Sprint(c.Value))+"%")
+	case GreaterOrEqual:
+		return db.Where(clause.Gte{Column: column, Value: c.Value})
+	case Less:
+		return db.Where(clause.Lt{Column: column, Value: c.Value})
+	default:
+		return db.Where(clause.Eq{Column: column, Value: c.Value})
+	}
+}
+
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// EscapeLike makes the LIKE wildcards in s match literally, for patterns
+// compared with ESCAPE '!'. "!" is used since a backslash is itself an
+// escape in MySQL strings.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,158 @@
+// Package query turns list request query strings into filtered, sorted
+// pages read with keyset pagination. Each listing declares the fields it can
+// be sorted and filtered by in a Spec, so clients cannot order or filter by
+// arbitrary, possibly unindexed columns.
+//
+// Pages are addressed by opaque cursors naming the last row seen rather than
+// by offsets, so paging is stable while rows are inserted and does not get
+// slower further into the table.
+package query
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	apperrors "github.com/shuv1824/go-api-starter/internal/common/errors"
+)
+
+// Field is a column that can be sorted or filtered by. Parse converts the
+// text of a query string or cursor into a value of the column's type.
+type Field struct {
+	Column string
+	Parse  func(string) (any, error)
+}
+
+func String(s string) (any, error) {
+	return s, nil
+}
+
+func Bool(s string) (any, error) {
+	return strconv.ParseBool(s)
+}
+
+// Time accepts RFC 3339 timestamps.
+func Time(s string) (any, error) {
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// Op is the comparison a filter applies to its field.
+type Op int
+
+const (
+	Equal Op = iota
+	Prefix
+	GreaterOrEqual
+	Less
+)
+
+type Filter struct {
+	Field Field
+	Op    Op
+}
+
+// Spec describes what a listing can be sorted and filtered by.
+type Spec struct {
+	// Sorts maps the names accepted by the sort parameter to fields. A
+	// leading "-" in the parameter sorts descending.
+	Sorts map[string]Field
+	// DefaultSort is used when the request names no sort, e.g.
+	// "-created_at".
+	DefaultSort string
+	// Filters maps query parameters to the filters they apply.
+	Filters map[string]Filter
+	// Key is a unique field breaking ties between rows with the same sort
+	// value, usually the primary key.
+	Key Field
+
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Condition is a filter with the value requested for it.
+type Condition struct {
+	Filter Filter
+	Value  any
+}
+
+// Params is a parsed list request.
+type Params struct {
+	Limit   int
+	Sort    string
+	Desc    bool
+	Filters []Condition
+
+	field  Field
+	key    Field
+	cursor *cursor
+}
+
+// Parse reads the limit, sort, cursor and filter parameters of a request.
+// Invalid values fail with an error wrapping ErrInvalidInput whose message
+// can be shown to the client. Parameters the spec does not know are ignored.
+func (s Spec) Parse(values url.Values) (Params, error) {
+	p := Params{Limit: s.DefaultLimit, key: s.Key}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > s.MaxLimit {
+			return Params{}, invalidf("limit: must be between 1 and %d", s.MaxLimit)
+		}
+		p.Limit = n
+	}
+
+	sort := values.Get("sort")
+	if token := values.Get("cursor"); token != "" {
+		c, err := decodeCursor(token)
+		if err != nil {
+			return Params{}, invalidf("cursor: is malformed")
+		}
+		if sort != "" && sort != c.Sort {
+			return Params{}, invalidf("cursor: was issued for sort %q", c.Sort)
+		}
+		sort = c.Sort
+		p.cursor = &c
+	}
+	if sort == "" {
+		sort = s.DefaultSort
+	}
+	p.Sort, p.Desc = strings.CutPrefix(sort, "-")
+	field, ok := s.Sorts[p.Sort]
+	if !ok {
+		return Params{}, invalidf("sort: must be one of %s", strings.Join(sortedKeys(s.Sorts), ", "))
+	}
+	p.field = field
+
+	if p.cursor != nil {
+		if err := p.cursor.parse(field, s.Key); err != nil {
+			return Params{}, invalidf("cursor: is malformed")
+		}
+	}
+
+	for _, name := range sortedKeys(s.Filters) {
+		raw, ok := values[name]
+		if !ok {
+			continue
+		}
+		filter := s.Filters[name]
+		value, err := filter.Field.Parse(raw[0])
+		if err != nil {
+			return Params{}, invalidf("%s: invalid value %q", name, raw[0])
+		}
+		p.Filters = append(p.Filters, Condition{Filter: filter, Value: value})
+	}
+
+	return p, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+func invalidf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", apperrors.ErrInvalidInput, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,206 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	apperrors "github.com/shuv1824/go-api-starter/internal/common/errors"
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+	"gorm.io/gorm"
+)
+
+type account struct {
+	ID        string `gorm:"primaryKey"`
+	Email     string
+	Active    bool
+	CreatedAt time.Time
+}
+
+var testSpec = Spec{
+	Sorts: map[string]Field{
+		"created_at": {Column: "created_at", Parse: Time},
+		"email":      {Column: "email", Parse: String},
+	},
+	DefaultSort: "-created_at",
+	Filters: map[string]Filter{
+		"email_prefix":   {Field: Field{Column: "email", Parse: String}, Op: Prefix},
+		"active":         {Field: Field{Column: "active", Parse: Bool}, Op: Equal},
+		"created_after":  {Field: Field{Column: "created_at", Parse: Time}, Op: GreaterOrEqual},
+		"created_before": {Field: Field{Column: "created_at", Parse: Time}, Op: Less},
+	},
+	Key:          Field{Column: "id", Parse: String},
+	DefaultLimit: 3,
+	MaxLimit:     10,
+}
+
+var base = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// setupAccounts stores seven accounts; several share a creation time, so
+// pages have to break ties by ID.
+func setupAccounts(t *testing.T) *gorm.DB {
+	t.Helper()
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = filepath.Join(t.TempDir(), "query.db")
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	if err := db.AutoMigrate(&account{}); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+
+	accounts := []account{
+		{ID: "a", Email: "ann@example.com", Active: true, CreatedAt: base},
+		{ID: "b", Email: "bob@example.com", Active: false, CreatedAt: base.Add(time.Hour)},
+		{ID: "c", Email: "b_c@example.com", Active: true, CreatedAt: base.Add(time.Hour)},
+		{ID: "d", Email: "bec@example.com", Active: true, CreatedAt: base.Add(time.Hour)},
+		{ID: "e", Email: "eve@example.com", Active: false, CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "f", Email: "fay@example.com", Active: true, CreatedAt: base.Add(3 * time.Hour)},
+		{ID: "g", Email: "gus@example.com", Active: true, CreatedAt: base.Add(3 * time.Hour)},
+	}
+	if err := db.Create(&accounts).Error; err != nil {
+		t.Fatalf("failed to create accounts: %v", err)
+	}
+	return db
+}
+
+func ids(accounts []*account) string {
+	var ids []string
+	for _, a := range accounts {
+		ids = append(ids, a.ID)
+	}
+	return fmt.Sprint(ids)
+}
+
+func findPage(t *testing.T, db *gorm.DB, values url.Values) Page[*account] {
+	t.Helper()
+	p, err := testSpec.Parse(values)
+	if err != nil {
+		t.Fatalf("failed to parse %v: %v", values, err)
+	}
+	page, err := Find[*account](db.Model(&account{}), p)
+	if err != nil {
+		t.Fatalf("failed to find page: %v", err)
+	}
+	return page
+}
+
+func TestFind_Paging(t *testing.T) {
+	db := setupAccounts(t)
+
+	tests := []struct {
+		sort  string
+		pages []string
+	}{
+		{sort: "", pages: []string{"[g f e]", "[d c b]", "[a]"}},
+		{sort: "created_at", pages: []string{"[a b c]", "[d e f]", "[g]"}},
+		{sort: "email", pages: []string{"[a c d]", "[b e f]", "[g]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run("sort "+tt.sort, func(t *testing.T) {
+			values := url.Values{}
+			if tt.sort != "" {
+				values.Set("sort", tt.sort)
+			}
+
+			var page Page[*account]
+			var forward []Page[*account]
+			for i, want := range tt.pages {
+				page = findPage(t, db, values)
+				if got := ids(page.Items); got != want {
+					t.Fatalf("page %d: expected %s, got %s", i, want, got)
+				}
+				if (page.PrevCursor != "") != (i > 0) {
+					t.Errorf("page %d: unexpected prev cursor %q", i, page.PrevCursor)
+				}
+				forward = append(forward, page)
+				values = url.Values{"cursor": {page.NextCursor}}
+			}
+			if page.NextCursor != "" {
+				t.Errorf("expected no next cursor on the last page, got %q", page.NextCursor)
+			}
+
+			for i := len(tt.pages) - 2; i >= 0; i-- {
+				page = findPage(t, db, url.Values{"cursor": {page.PrevCursor}})
+				if got := ids(page.Items); got != tt.pages[i] {
+					t.Fatalf("page %d backwards: expected %s, got %s", i, tt.pages[i], got)
+				}
+				if page.NextCursor != forward[i].NextCursor {
+					t.Errorf("page %d backwards: expected the same next cursor as forwards", i)
+				}
+			}
+			if page.PrevCursor != "" {
+				t.Errorf("expected no prev cursor on the first page, got %q", page.PrevCursor)
+			}
+		})
+	}
+}
+
+func TestFind_Filters(t *testing.T) {
+	db := setupAccounts(t)
+
+	tests := []struct {
+		name   string
+		values url.Values
+		want   string
+	}{
+		{"prefix", url.Values{"email_prefix": {"b"}}, "[d c b]"},
+		{"prefix with wildcard", url.Values{"email_prefix": {"b_"}}, "[c]"},
+		{"bool", url.Values{"active": {"false"}}, "[e b]"},
+		{"time range", url.Values{"created_after": {base.Add(time.Hour).Format(time.RFC3339)}, "created_before": {base.Add(3 * time.Hour).Format(time.RFC3339)}, "limit": {"10"}}, "[e d c b]"},
+		{"combined", url.Values{"active": {"true"}, "email_prefix": {"b"}}, "[d c]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(findPage(t, db, tt.values).Items); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSpec_ParseErrors(t *testing.T) {
+	db := setupAccounts(t)
+	next := findPage(t, db, url.Values{}).NextCursor
+
+	tests := []struct {
+		name   string
+		values url.Values
+	}{
+		{"limit not a number", url.Values{"limit": {"ten"}}},
+		{"limit too large", url.Values{"limit": {"11"}}},
+		{"limit zero", url.Values{"limit": {"0"}}},
+		{"unknown sort", url.Values{"sort": {"password"}}},
+		{"malformed cursor", url.Values{"cursor": {"not-a-cursor"}}},
+		{"cursor for another sort", url.Values{"cursor": {next}, "sort": {"email"}}},
+		{"invalid filter value", url.Values{"active": {"maybe"}}},
+		{"invalid time", url.Values{"created_after": {"yesterday"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := testSpec.Parse(tt.values)
+			if !errors.Is(err, apperrors.ErrInvalidInput) {
+				t.Errorf("expected invalid input, got %v", err)
+			}
+		})
+	}
+
+	p, err := testSpec.Parse(url.Values{"cursor": {next}, "sort": {"-created_at"}})
+	if err != nil {
+		t.Fatalf("expected cursor with its own sort to be accepted, got %v", err)
+	}
+	if p.Sort != "created_at" || !p.Desc {
+		t.Errorf("expected descending created_at, got %s desc=%v", p.Sort, p.Desc)
+	}
+}
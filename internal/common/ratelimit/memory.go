@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	window   time.Time
+	size     time.Duration
+	current  int64
+	previous int64
+}
+
+type memoryShard struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// MemoryStore is an in-process Store. Keys are spread over several shards so
+// that concurrent requests for different clients rarely contend on a lock.
+type MemoryStore struct {
+	shards []*memoryShard
+	stop   chan struct{}
+	once   sync.Once
+}
+
+// NewMemoryStore creates a store with the given number of shards and starts a
+// janitor that drops expired entries every cleanupInterval.
+func NewMemoryStore(shards int, cleanupInterval time.Duration) *MemoryStore {
+	if shards <= 0 {
+		shards = 32
+	}
+
+	s := &MemoryStore{
+		shards: make([]*memoryShard, shards),
+		stop:   make(chan struct{}),
+	}
+	for i := range s.shards {
+		s.shards[i] = &memoryShard{entries: make(map[string]*memoryEntry)}
+	}
+
+	if cleanupInterval > 0 {
+		go s.janitor(cleanupInterval)
+	}
+
+	return s
+}
+
+func (s *MemoryStore) Increment(ctx context.Context, key string, window time.Time, size time.Duration) (int64, int64, error) {
+	shard := s.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	entry, ok := shard.entries[key]
+	switch {
+	case !ok:
+		entry = &memoryEntry{window: window, size: size}
+		shard.entries[key] = entry
+	case entry.window.Equal(window):
+	case entry.window.Add(size).Equal(window):
+		entry.previous = entry.current
+		entry.current = 0
+		entry.window = window
+	default:
+		entry.previous = 0
+		entry.current = 0
+		entry.window = window
+	}
+	entry.size = size
+	entry.current++
+
+	return entry.current, entry.previous, nil
+}
+
+// Cleanup removes entries whose windows can no longer affect a decision.
+func (s *MemoryStore) Cleanup(now time.Time) {
+	for _, shard := range s.shards {
+		shard.mu.Lock()
+		for key, entry := range shard.entries {
+			if now.After(entry.window.Add(2 * entry.size)) {
+				delete(shard.entries, key)
+			}
+		}
+		shard.mu.Unlock()
+	}
+}
+
+// Close stops the janitor goroutine.
+func (s *MemoryStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *MemoryStore) shard(key string) *memoryShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+func (s *MemoryStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.Cleanup(time.Now())
+		case <-s.stop:
+			return
+		}
+	}
+}
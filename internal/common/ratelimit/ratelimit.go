@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Rule describes how many requests are allowed within a window.
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Enabled reports whether the rule actually limits anything.
+func (r Rule) Enabled() bool {
+	return r.Limit > 0 && r.Window > 0
+}
+
+// Result is the outcome of a single Allow call.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps the hit counters used by the limiter. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Increment records a hit for key in the window starting at window and
+	// returns the hit counts of that window and of the window before it.
+	Increment(ctx context.Context, key string, window time.Time, size time.Duration) (current, previous int64, err error)
+}
+
+// Limiter implements a sliding window rate limiter on top of a Store.
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow records a request for key and reports whether it fits in rule.
+//
+// The previous window's count is weighted by how much of it still overlaps
+// the sliding window, which smooths out bursts at window boundaries without
+// keeping a log of every request.
+func (l *Limiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	now := l.now()
+	window := now.Truncate(rule.Window)
+
+	current, previous, err := l.store.Increment(ctx, key, window, rule.Window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	elapsed := now.Sub(window)
+	weight := 1 - float64(elapsed)/float64(rule.Window)
+	estimate := float64(previous)*weight + float64(current)
+	untilReset := rule.Window - elapsed
+
+	result := Result{
+		Allowed:   estimate <= float64(rule.Limit),
+		Limit:     rule.Limit,
+		Remaining: max(rule.Limit-int(math.Ceil(estimate)), 0),
+		Reset:     untilReset,
+	}
+
+	if !result.Allowed {
+		result.RetryAfter = untilReset
+		if previous > 0 && float64(current) <= float64(rule.Limit) {
+			// Time until enough of the previous window has slid out.
+			wait := time.Duration((estimate - float64(rule.Limit)) / float64(previous) * float64(rule.Window))
+			if wait < untilReset {
+				result.RetryAfter = wait
+			}
+		}
+	}
+
+	return result, nil
+}
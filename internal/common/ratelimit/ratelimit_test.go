@@ -0,0 +1,216 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/internal/migration"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+)
+
+func newTestLimiter(now *time.Time) *Limiter {
+	limiter := NewLimiter(NewMemoryStore(4, 0))
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	rule := Rule{Limit: 3, Window: time.Minute}
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(context.Background(), "client", rule)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Fatalf("request %d should be allowed", i+1)
+		}
+		if result.Remaining != 2-i {
+			t.Errorf("expected remaining %d, got %d", 2-i, result.Remaining)
+		}
+	}
+
+	result, err := limiter.Allow(context.Background(), "client", rule)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Allowed {
+		t.Error("fourth request should be rejected")
+	}
+	if result.RetryAfter <= 0 || result.RetryAfter > time.Minute {
+		t.Errorf("unexpected retry after: %v", result.RetryAfter)
+	}
+
+	// Other keys have their own budget
+	result, _ = limiter.Allow(context.Background(), "other", rule)
+	if !result.Allowed {
+		t.Error("request for a different key should be allowed")
+	}
+}
+
+func TestLimiter_SlidingWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestLimiter(&now)
+	rule := Rule{Limit: 4, Window: time.Minute}
+
+	for i := 0; i < 4; i++ {
+		limiter.Allow(context.Background(), "client", rule)
+	}
+
+	// A quarter into the next window 75% of the previous hits still count.
+	now = now.Add(time.Minute + 15*time.Second)
+	result, _ := limiter.Allow(context.Background(), "client", rule)
+	if !result.Allowed {
+		t.Fatal("request should be allowed: estimate is 4*0.75+1 = 4")
+	}
+	result, _ = limiter.Allow(context.Background(), "client", rule)
+	if result.Allowed {
+		t.Fatal("request should be rejected: estimate is 4*0.75+2 = 5")
+	}
+
+	// Two windows later nothing from the first window is left.
+	now = now.Add(2 * time.Minute)
+	result, _ = limiter.Allow(context.Background(), "client", rule)
+	if !result.Allowed || result.Remaining != 3 {
+		t.Errorf("expected fresh budget, got %+v", result)
+	}
+}
+
+func TestMemoryStore_Concurrent(t *testing.T) {
+	store := NewMemoryStore(8, 0)
+	window := time.Now().Truncate(time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			store.Increment(context.Background(), fmt.Sprintf("key-%d", i%5), window, time.Minute)
+		}(i)
+	}
+	wg.Wait()
+
+	for i := 0; i < 5; i++ {
+		current, _, _ := store.Increment(context.Background(), fmt.Sprintf("key-%d", i), window, time.Minute)
+		if current != 11 {
+			t.Errorf("key-%d: expected 11 hits, got %d", i, current)
+		}
+	}
+}
+
+func TestMemoryStore_Cleanup(t *testing.T) {
+	store := NewMemoryStore(1, 0)
+	window := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.Increment(context.Background(), "client", window, time.Minute)
+
+	store.Cleanup(window.Add(time.Minute))
+	if len(store.shards[0].entries) != 1 {
+		t.Fatal("entry should survive while it can still affect decisions")
+	}
+
+	store.Cleanup(window.Add(3 * time.Minute))
+	if len(store.shards[0].entries) != 0 {
+		t.Error("expired entry should be removed")
+	}
+}
+
+func newTestSQLStore(t *testing.T) *SQLStore {
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = ":memory:"
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	if err := migration.MigrateUp(db, cfg.Type, 0); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return NewSQLStore(db, 0)
+}
+
+func TestSQLStore_Increment(t *testing.T) {
+	store := newTestSQLStore(t)
+	ctx := context.Background()
+	window := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 3; i++ {
+		current, previous, err := store.Increment(ctx, "client", window, time.Minute)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if current != int64(i) || previous != 0 {
+			t.Errorf("hit %d: expected %d/0, got %d/%d", i, i, current, previous)
+		}
+	}
+
+	current, previous, err := store.Increment(ctx, "client", window.Add(time.Minute), time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if current != 1 || previous != 3 {
+		t.Errorf("expected the next window to see 1/3, got %d/%d", current, previous)
+	}
+
+	if current, _, _ := store.Increment(ctx, "other", window, time.Minute); current != 1 {
+		t.Errorf("expected other keys to be counted apart, got %d", current)
+	}
+}
+
+func TestSQLStore_Concurrent(t *testing.T) {
+	store := newTestSQLStore(t)
+	window := time.Now().Truncate(time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := store.Increment(context.Background(), "client", window, time.Minute); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if current, _, _ := store.Increment(context.Background(), "client", window, time.Minute); current != 21 {
+		t.Errorf("expected 21 hits, got %d", current)
+	}
+}
+
+func TestSQLStore_Cleanup(t *testing.T) {
+	store := newTestSQLStore(t)
+	ctx := context.Background()
+	window := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store.Increment(ctx, "client", window, time.Minute)
+
+	count := func() int64 {
+		t.Helper()
+		var n int64
+		if err := store.db.Model(&sqlEntry{}).Count(&n).Error; err != nil {
+			t.Fatalf("failed to count entries: %v", err)
+		}
+		return n
+	}
+
+	if err := store.Cleanup(ctx, window.Add(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count() != 1 {
+		t.Fatal("entry should survive while it can still affect decisions")
+	}
+
+	if err := store.Cleanup(ctx, window.Add(3*time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count() != 0 {
+		t.Error("expired entry should be removed")
+	}
+}
@@ -0,0 +1,110 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type sqlEntry struct {
+	Bucket      string    `gorm:"primaryKey;size:255"`
+	WindowStart int64     `gorm:"primaryKey"`
+	Hits        int64     `gorm:"not null"`
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
+
+func (sqlEntry) TableName() string {
+	return "rate_limits"
+}
+
+// SQLStore keeps counters in the rate_limits table so that several API
+// instances share the same limits.
+type SQLStore struct {
+	db   *gorm.DB
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewSQLStore creates a store backed by db and starts a janitor that deletes
+// expired rows every cleanupInterval.
+func NewSQLStore(db *gorm.DB, cleanupInterval time.Duration) *SQLStore {
+	s := &SQLStore{
+		db:   db,
+		stop: make(chan struct{}),
+	}
+
+	if cleanupInterval > 0 {
+		go s.janitor(cleanupInterval)
+	}
+
+	return s
+}
+
+func (s *SQLStore) Increment(ctx context.Context, key string, window time.Time, size time.Duration) (int64, int64, error) {
+	db := s.db.WithContext(ctx)
+
+	entry := sqlEntry{
+		Bucket:      key,
+		WindowStart: window.UnixMilli(),
+		Hits:        1,
+		ExpiresAt:   window.Add(2 * size),
+	}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "bucket"}, {Name: "window_start"}},
+		DoUpdates: clause.Assignments(map[string]any{"hits": gorm.Expr("rate_limits.hits + 1")}),
+	}).Create(&entry).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var entries []sqlEntry
+	err = db.Where("bucket = ? AND window_start IN ?", key, []int64{
+		window.UnixMilli(),
+		window.Add(-size).UnixMilli(),
+	}).Find(&entries).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var current, previous int64
+	for _, e := range entries {
+		if e.WindowStart == window.UnixMilli() {
+			current = e.Hits
+		} else {
+			previous = e.Hits
+		}
+	}
+
+	return current, previous, nil
+}
+
+// Cleanup deletes rows whose windows can no longer affect a decision.
+func (s *SQLStore) Cleanup(ctx context.Context, now time.Time) error {
+	return s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&sqlEntry{}).Error
+}
+
+// Close stops the janitor goroutine.
+func (s *SQLStore) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	return nil
+}
+
+func (s *SQLStore) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Cleanup(context.Background(), time.Now()); err != nil {
+				slog.Error("failed to clean up rate limit entries", "error", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
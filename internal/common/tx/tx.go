@@ -0,0 +1,110 @@
+// Package tx runs service operations in database transactions. Repositories
+// get the transaction from the context with DB, so writes made through
+// several repositories commit or roll back together.
+package tx
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"gorm.io/gorm"
+)
+
+// Manager runs fn in a transaction that commits when fn returns nil and rolls
+// back otherwise. fn must pass on the context it receives. When ctx already
+// carries a transaction, fn runs in a nested one backed by a savepoint, so
+// its failure only undoes its own writes.
+type Manager interface {
+	Run(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying the transaction tx. Managers
+// call it; other code only needs it to hand a transaction to repositories
+// in tests.
+func NewContext(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, contextKey{}, tx)
+}
+
+func fromContext(ctx context.Context) *gorm.DB {
+	tx, _ := ctx.Value(contextKey{}).(*gorm.DB)
+	return tx
+}
+
+// DB returns the transaction carried by ctx, or db when there is none,
+// bound to ctx.
+func DB(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx := fromContext(ctx); tx != nil {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// GormManager runs transactions on a GORM database. Transactions failing
+// with a serialization failure or deadlock are retried from the start, so fn
+// may run more than once and must not have side effects outside the
+// database.
+type GormManager struct {
+	db    *gorm.DB
+	retry config.RetryConfig
+}
+
+func NewManager(db *gorm.DB, retry config.RetryConfig) *GormManager {
+	return &GormManager{db: db, retry: retry}
+}
+
+func (m *GormManager) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if current := fromContext(ctx); current != nil {
+		// Nested transactions are savepoints and cannot be retried on
+		// their own, the outermost Run retries the whole transaction.
+		return current.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(NewContext(ctx, tx))
+		})
+	}
+
+	attempts := max(m.retry.Attempts, 1)
+	backoff := m.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(NewContext(ctx, tx))
+		})
+		if err == nil || attempt >= attempts || !IsRetryable(err) {
+			return err
+		}
+
+		slog.WarnContext(ctx, "transaction conflicted, retrying", "attempt", attempt, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, m.retry.MaxBackoff)
+	}
+}
+
+// IsRetryable reports whether err means the transaction lost a conflict
+// with a concurrent one and may succeed when run again.
+func IsRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		// serialization_failure and deadlock_detected
+		return pgErr.Code == "40001" || pgErr.Code == "40P01"
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if errors.As(err, &mysqlErr) {
+		// ER_LOCK_DEADLOCK and ER_LOCK_WAIT_TIMEOUT
+		return mysqlErr.Number == 1213 || mysqlErr.Number == 1205
+	}
+
+	// SQLite reports a conflicting lock held by another connection as busy;
+	// matched by message so this package does not depend on a SQLite driver.
+	return err != nil && strings.Contains(err.Error(), "database is locked")
+}
@@ -0,0 +1,197 @@
+package tx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+	"gorm.io/gorm"
+)
+
+type item struct {
+	Name string
+}
+
+func setupManager(t *testing.T) (*GormManager, *gorm.DB) {
+	t.Helper()
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = filepath.Join(t.TempDir(), "tx.db")
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { database.Close(db) })
+	if err := db.AutoMigrate(&item{}); err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	return NewManager(db, config.RetryConfig{Attempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}), db
+}
+
+func insert(ctx context.Context, db *gorm.DB, name string) error {
+	return DB(ctx, db).Create(&item{Name: name}).Error
+}
+
+func names(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var names []string
+	if err := db.Model(&item{}).Order("name").Pluck("name", &names).Error; err != nil {
+		t.Fatalf("failed to list items: %v", err)
+	}
+	return names
+}
+
+func TestGormManager_CommitAndRollback(t *testing.T) {
+	manager, db := setupManager(t)
+	ctx := context.Background()
+
+	err := manager.Run(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, db, "a"); err != nil {
+			return err
+		}
+		return insert(ctx, db, "b")
+	})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+
+	errFailed := errors.New("failed")
+	err = manager.Run(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, db, "c"); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if !errors.Is(err, errFailed) {
+		t.Errorf("expected the function's error, got %v", err)
+	}
+
+	if got := fmt.Sprint(names(t, db)); got != "[a b]" {
+		t.Errorf("expected only the committed items, got %s", got)
+	}
+}
+
+func TestGormManager_NestedSavepoint(t *testing.T) {
+	manager, db := setupManager(t)
+	ctx := context.Background()
+
+	err := manager.Run(ctx, func(ctx context.Context) error {
+		if err := insert(ctx, db, "outer"); err != nil {
+			return err
+		}
+		nestedErr := manager.Run(ctx, func(ctx context.Context) error {
+			if err := insert(ctx, db, "nested"); err != nil {
+				return err
+			}
+			return errors.New("nested failed")
+		})
+		if nestedErr == nil {
+			t.Error("expected nested transaction to fail")
+		}
+		return manager.Run(ctx, func(ctx context.Context) error {
+			return insert(ctx, db, "sibling")
+		})
+	})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+
+	if got := fmt.Sprint(names(t, db)); got != "[outer sibling]" {
+		t.Errorf("expected the failed savepoint to be rolled back, got %s", got)
+	}
+}
+
+func TestGormManager_Retry(t *testing.T) {
+	serializationFailure := &pgconn.PgError{Code: "40001"}
+
+	tests := []struct {
+		name         string
+		failures     int
+		err          error
+		wantAttempts int
+		wantErr      bool
+	}{
+		{"succeeds after conflict", 2, serializationFailure, 3, false},
+		{"gives up after attempts", 5, serializationFailure, 3, true},
+		{"does not retry other errors", 1, errors.New("constraint violated"), 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, db := setupManager(t)
+			attempts := 0
+			err := manager.Run(context.Background(), func(ctx context.Context) error {
+				attempts++
+				if err := insert(ctx, db, fmt.Sprint("attempt-", attempts)); err != nil {
+					return err
+				}
+				if attempts <= tt.failures {
+					return fmt.Errorf("update failed: %w", tt.err)
+				}
+				return nil
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("expected %d attempts, got %d", tt.wantAttempts, attempts)
+			}
+			wantRows := 0
+			if !tt.wantErr {
+				wantRows = 1
+			}
+			if got := len(names(t, db)); got != wantRows {
+				t.Errorf("expected %d rows from the last attempt, got %d", wantRows, got)
+			}
+		})
+	}
+}
+
+func TestGormManager_RetryStopsOnCancel(t *testing.T) {
+	manager, _ := setupManager(t)
+	manager.retry = config.RetryConfig{Attempts: 3, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := manager.Run(ctx, func(ctx context.Context) error {
+		cancel()
+		return &pgconn.PgError{Code: "40P01"}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation to stop retries, got %v", err)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"postgres serialization failure", &pgconn.PgError{Code: "40001"}, true},
+		{"postgres deadlock", &pgconn.PgError{Code: "40P01"}, true},
+		{"postgres unique violation", &pgconn.PgError{Code: "23505"}, false},
+		{"mysql deadlock", &mysqldriver.MySQLError{Number: 1213}, true},
+		{"mysql lock wait timeout", &mysqldriver.MySQLError{Number: 1205}, true},
+		{"mysql duplicate entry", &mysqldriver.MySQLError{Number: 1062}, false},
+		{"sqlite busy", errors.New("database is locked"), true},
+		{"other", errors.New("connection refused"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+// Package txtest provides a tx.Manager for unit tests of services that use
+// repository fakes instead of a database.
+package txtest
+
+import (
+	"context"
+	"sync"
+)
+
+// Manager runs functions directly and records how their transactions
+// ended. Nested calls are recorded like top-level ones.
+type Manager struct {
+	// Err, if set, is returned by Run without calling fn, like a
+	// transaction that could not be started.
+	Err error
+
+	mu        sync.Mutex
+	commits   int
+	rollbacks int
+}
+
+func (m *Manager) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.Err != nil {
+		return m.Err
+	}
+
+	err := fn(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.rollbacks++
+	} else {
+		m.commits++
+	}
+	return err
+}
+
+// Commits returns how many transactions committed.
+func (m *Manager) Commits() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.commits
+}
+
+// Rollbacks returns how many transactions rolled back.
+func (m *Manager) Rollbacks() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rollbacks
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// writeConfig writes a config file with a valid secret followed by content.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content = "secret: " + testSecret + "\n" + content
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestLoad_Layers(t *testing.T) {
+	path := writeConfig(t, `
+port: 8080
+database:
+  host: file-host
+  password: file-password
+`)
+
+	t.Setenv("TEST_DATABASE_PASSWORD", "env-password")
+	t.Setenv("TEST_DATABASE_HOST", "env-host")
+	t.Setenv("TEST_RATE_LIMIT_AUTH_WINDOW", "30s")
+
+	cfg, err := Load(LoadOptions{
+		Path:      path,
+		EnvPrefix: "TEST",
+		Overrides: map[string]string{"database.host": "flag-host"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Mode != ModeTypeDebug {
+		t.Errorf("expected default mode, got %s", cfg.Mode)
+	}
+	if cfg.Secret.Value() != testSecret {
+		t.Errorf("expected secret from file, got %s", cfg.Secret.Value())
+	}
+	if cfg.Database.Password.Value() != "env-password" {
+		t.Errorf("expected password from env, got %s", cfg.Database.Password.Value())
+	}
+	if cfg.Database.Host != "flag-host" {
+		t.Errorf("expected host from override, got %s", cfg.Database.Host)
+	}
+	if cfg.RateLimit.Auth.Window != 30*time.Second {
+		t.Errorf("expected window from env, got %s", cfg.RateLimit.Auth.Window)
+	}
+}
+
+func TestLoad_PathFromEnv(t *testing.T) {
+	path := writeConfig(t, "port: 9999\n")
+	t.Setenv("TEST_CONFIG", path)
+
+	cfg, err := Load(LoadOptions{EnvPrefix: "TEST"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Port != 9999 {
+		t.Errorf("expected port from file named by env, got %d", cfg.Port)
+	}
+}
+
+func TestLoad_MissingExplicitFile(t *testing.T) {
+	_, err := Load(LoadOptions{Path: filepath.Join(t.TempDir(), "missing.yaml")})
+	if err == nil {
+		t.Error("expected error for missing explicit config file")
+	}
+}
+
+func TestLoad_InvalidEnvDoesNotLeakValue(t *testing.T) {
+	t.Setenv("TEST_PORT", "not-a-port-s3cr3t")
+
+	_, err := Load(LoadOptions{Path: writeConfig(t, ""), EnvPrefix: "TEST"})
+	if err == nil {
+		t.Fatal("expected error for invalid port")
+	}
+	if !strings.Contains(err.Error(), "TEST_PORT") || strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestConfig_Set(t *testing.T) {
+	cfg := Default()
+
+	if err := cfg.Set("idempotency.ttl", "1h"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Idempotency.TTL != time.Hour {
+		t.Errorf("expected ttl 1h, got %s", cfg.Idempotency.TTL)
+	}
+
+	if err := cfg.Set("database.nope", "x"); err == nil {
+		t.Error("expected error for unknown key")
+	}
+}
+
+func TestLoad_ReportsAllProblems(t *testing.T) {
+	path := writeConfig(t, `
+mode: production
+port: 70000
+databse:
+  host: typo
+database:
+  type: oracle
+  max_open_conns: 5
+  max_idle_conns: 10
+  retry:
+    attempts: 0
+rate_limit:
+  auth:
+    requests: 10
+idempotency:
+  enabled: true
+  lease: 48h
+`)
+
+	_, err := Load(LoadOptions{Path: path})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %v", err)
+	}
+
+	for _, want := range []string{
+		"field databse not found",
+		"mode: unknown mode",
+		"port: 70000 is out of range",
+		"database.type: unsupported database type",
+		"database.max_idle_conns: 10 exceeds max_open_conns 5",
+		"database.retry.attempts: must be at least 1",
+		"rate_limit.auth.window: must be positive",
+		"idempotency.lease: 48h0m0s exceeds ttl 24h0m0s",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestConfig_ValidateSecret(t *testing.T) {
+	cfg := Default()
+
+	cfg.Secret = NewSecret("")
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "secret: is required") {
+		t.Errorf("expected missing secret error, got %v", err)
+	}
+
+	cfg.Secret = NewSecret("short")
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "at least 32 bytes") {
+		t.Errorf("expected short secret error, got %v", err)
+	}
+
+	cfg.Secret = NewSecret(testSecret)
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("expected default config with a secret to be valid, got %v", err)
+	}
+}
+
+func TestCORSConfig_Validate(t *testing.T) {
+	cors := Default().CORS
+	if err := cors.Validate(); err != nil {
+		t.Errorf("expected default CORS config to be valid, got %v", err)
+	}
+
+	cors.AllowCredentials = true
+	if err := cors.Validate(); err == nil || !strings.Contains(err.Error(), "allow_credentials: cannot be combined") {
+		t.Errorf("expected wildcard origin with credentials to be rejected, got %v", err)
+	}
+
+	cors.AllowedOrigins = []string{"https://app.example.com"}
+	if err := cors.Validate(); err != nil {
+		t.Errorf("expected listed origins with credentials to be valid, got %v", err)
+	}
+}
+
+func TestServerConfig_ValidateTrustedProxies(t *testing.T) {
+	server := Default().Server
+	server.TrustedProxies = []string{"10.0.0.1", "192.168.0.0/16", "::1"}
+	if err := server.Validate(); err != nil {
+		t.Errorf("expected IPs and CIDRs to be valid, got %v", err)
+	}
+
+	server.TrustedProxies = []string{"proxy.internal"}
+	if err := server.Validate(); err == nil || !strings.Contains(err.Error(), `trusted_proxies: "proxy.internal"`) {
+		t.Errorf("expected a host name to be rejected, got %v", err)
+	}
+}
+
+func TestDatabaseConfig_ValidateReplicas(t *testing.T) {
+	t.Setenv("TEST_DATABASE_REPLICAS_HOSTS", "replica-1:5433,replica-2")
+	cfg, err := Load(LoadOptions{Path: writeConfig(t, ""), EnvPrefix: "TEST"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Database.Replicas.Hosts; len(got) != 2 || got[0] != "replica-1:5433" || got[1] != "replica-2" {
+		t.Errorf("expected replica hosts from env, got %v", got)
+	}
+
+	db := Default().Database
+	db.Type = "sqlite"
+	db.Replicas = ReplicasConfig{
+		Hosts:  []string{"replica:99999"},
+		Policy: "least_conn",
+	}
+	err = db.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		"replicas: are not supported for sqlite",
+		"replicas.hosts: port \"99999\"",
+		"replicas.policy: unknown policy",
+		"replicas.health_check_interval: must be positive",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestDatabaseConfig_ValidateConnection(t *testing.T) {
+	db := Default().Database
+	db.URL = NewSecret("postgres://app@db/app")
+	db.Host = ""
+	db.DbName = ""
+	if err := db.Validate(); err != nil {
+		t.Errorf("expected url to replace host and dbname, got %v", err)
+	}
+
+	db.Options = map[string]string{"search_path": "app"}
+	db.MaxOpenConns, db.MaxIdleConns = 1, 1
+	db.SSLMode = "verify-everything"
+	db.TLS = TLSConfig{CertFile: "client.pem"}
+	err := db.Validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, want := range []string{
+		"options: cannot be combined with url",
+		"max_open_conns: must be 0 (unlimited) or at least 2",
+		"sslmode: unknown mode \"verify-everything\"",
+		"tls: cert_file and key_file must be set together",
+		"tls: cannot be combined with url",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"sync"
+)
+
+// databaseTypes maps the database types validation accepts to whether they
+// are embedded, i.e. opened from a local file instead of a server. Drivers
+// registered with pkg/database are added here.
+var (
+	databaseTypesMu sync.RWMutex
+	databaseTypes   = map[string]bool{
+		"postgres": false,
+		"mysql":    false,
+		"sqlite":   true,
+	}
+)
+
+// RegisterDatabaseType makes validation accept typ as database.type.
+func RegisterDatabaseType(typ string, embedded bool) {
+	databaseTypesMu.Lock()
+	defer databaseTypesMu.Unlock()
+
+	databaseTypes[typ] = embedded
+}
+
+// DatabaseTypes returns the accepted database types, sorted.
+func DatabaseTypes() []string {
+	databaseTypesMu.RLock()
+	defer databaseTypesMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(databaseTypes))
+}
+
+func lookupDatabaseType(typ string) (embedded, known bool) {
+	databaseTypesMu.RLock()
+	defer databaseTypesMu.RUnlock()
+
+	embedded, known = databaseTypes[typ]
+	return embedded, known
+}
@@ -0,0 +1,197 @@
+package config
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+var (
+	secretType          = reflect.TypeOf(Secret{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// field is a leaf of the configuration tree addressed by its YAML path.
+type field struct {
+	path  string
+	value reflect.Value
+
+	// reloadable is set when the field, or a section containing it, is
+	// tagged reload:"true" and may change without a restart.
+	reloadable bool
+	// dsn is set for a Secret tagged secret:"dsn", holding a connection
+	// string.
+	dsn bool
+}
+
+// fields lists the leaves of cfg. Nested structs are flattened into dotted
+// paths; everything else, including maps, slices and types that unmarshal
+// themselves from text such as Secret, is a leaf.
+func (c *Config) fields() []field {
+	var out []field
+	collectFields(reflect.ValueOf(c).Elem(), "", false, &out)
+	return out
+}
+
+func collectFields(v reflect.Value, prefix string, reloadable bool, out *[]field) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !sf.IsExported() {
+			continue
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		fv := v.Field(i)
+		fieldReloadable := reloadable || sf.Tag.Get("reload") == "true"
+		if fv.Kind() == reflect.Struct && !reflect.PointerTo(fv.Type()).Implements(textUnmarshalerType) {
+			collectFields(fv, path, fieldReloadable, out)
+			continue
+		}
+
+		*out = append(*out, field{path: path, value: fv, reloadable: fieldReloadable, dsn: sf.Tag.Get("secret") == "dsn"})
+	}
+}
+
+// Set assigns value, given in its textual form, to the key at the dotted
+// YAML path, e.g. Set("database.port", "5433").
+func (c *Config) Set(path, value string) error {
+	for _, f := range c.fields() {
+		if f.path == path {
+			if err := setValue(f.value, value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", path, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown configuration key: %s", path)
+}
+
+// EnvName returns the environment variable overriding the key at path,
+// e.g. APP_DATABASE_PASSWORD for database.password.
+func EnvName(prefix, path string) string {
+	name := strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+	return prefix + "_" + name
+}
+
+func (c *Config) applyEnv(prefix string) error {
+	for _, f := range c.fields() {
+		name := EnvName(prefix, f.path)
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// resolveSecrets looks up every Secret given as a provider reference.
+func (c *Config) resolveSecrets(ctx context.Context) error {
+	for _, f := range c.fields() {
+		if f.value.Type() != secretType {
+			continue
+		}
+		secret := f.value.Addr().Interface().(*Secret)
+		if err := secret.resolve(ctx, f.dsn); err != nil {
+			return fmt.Errorf("failed to resolve secret %s: %w", f.path, err)
+		}
+	}
+	return nil
+}
+
+// setValue parses raw into v. Errors never include raw since it may be a
+// secret.
+func setValue(v reflect.Value, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("expected a duration such as 30s")
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("expected a number")
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		items := splitList(raw)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			slice.Index(i).SetString(item)
+		}
+		v.Set(slice)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		m := reflect.MakeMap(v.Type())
+		for _, item := range splitList(raw) {
+			key, value, ok := strings.Cut(item, "=")
+			if !ok {
+				return fmt.Errorf("expected comma separated key=value pairs")
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(elem, value); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce groups the burst of events editors and Kubernetes produce
+// when replacing a file into a single reload.
+const reloadDebounce = 200 * time.Millisecond
+
+// Manager holds the live configuration and swaps it atomically when the
+// config file changes or the process receives SIGHUP. Only fields tagged
+// reload:"true" are applied; changes to any other field are logged and
+// ignored until the next restart.
+type Manager struct {
+	opts    LoadOptions
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex
+	subscribers []func(old, new *Config)
+}
+
+func NewManager(cfg *Config, opts LoadOptions) *Manager {
+	m := &Manager{opts: opts}
+	m.current.Store(cfg)
+	return m
+}
+
+// Current returns the configuration in effect. The returned value must be
+// treated as read-only.
+func (m *Manager) Current() *Config {
+	return m.current.Load()
+}
+
+// Subscribe calls fn with the section chosen by selector whenever a reload
+// changes it.
+func Subscribe[T any](m *Manager, selector func(*Config) T, fn func(T)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscribers = append(m.subscribers, func(old, new *Config) {
+		if next := selector(new); !reflect.DeepEqual(selector(old), next) {
+			fn(next)
+		}
+	})
+}
+
+// Reload reads the configuration again and applies its reloadable fields.
+// An invalid configuration is rejected as a whole.
+func (m *Manager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	loaded, err := Load(m.opts)
+	if err != nil {
+		return err
+	}
+
+	old := m.current.Load()
+	next := *loaded
+	for _, path := range keepNonReloadable(old, &next) {
+		slog.Warn("config change requires a restart, keeping current value", "key", path)
+	}
+
+	m.current.Store(&next)
+	slog.Info("configuration reloaded")
+
+	for _, notify := range m.subscribers {
+		notify(old, &next)
+	}
+	return nil
+}
+
+// Watch reloads the configuration when the config file changes or SIGHUP is
+// received, until ctx is cancelled. Reload failures are logged and the
+// previous configuration stays in effect. Once it returns SIGHUP is ignored,
+// since its default action would terminate the process.
+func (m *Manager) Watch(ctx context.Context) error {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer func() {
+		signal.Ignore(syscall.SIGHUP)
+		signal.Stop(hup)
+	}()
+
+	path, _ := m.opts.FilePath()
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// Watch the directory rather than the file: editors and Kubernetes
+	// ConfigMaps replace the file, which would drop a watch on it.
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to watch config directory: %w", err)
+	}
+
+	reload := func(reason string) {
+		if err := m.Reload(); err != nil {
+			slog.Error("failed to reload configuration", "reason", reason, "error", err)
+		}
+	}
+
+	debounce := time.NewTimer(time.Hour)
+	debounce.Stop()
+	defer debounce.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			reload("signal")
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == path || filepath.Base(event.Name) == "..data" {
+				debounce.Reset(reloadDebounce)
+			}
+		case <-debounce.C:
+			reload("file changed")
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Error("config watcher error", "error", err)
+		}
+	}
+}
+
+// keepNonReloadable copies every field that may not change at runtime from
+// old into next and returns the paths of those that differed.
+func keepNonReloadable(old, next *Config) []string {
+	var changed []string
+
+	oldFields := old.fields()
+	for i, f := range next.fields() {
+		if f.reloadable {
+			continue
+		}
+		if previous := oldFields[i].value; !reflect.DeepEqual(previous.Interface(), f.value.Interface()) {
+			changed = append(changed, f.path)
+			f.value.Set(previous)
+		}
+	}
+
+	return changed
+}
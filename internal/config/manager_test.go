@@ -0,0 +1,108 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestManager_Reload(t *testing.T) {
+	path := writeConfig(t, `
+port: 8080
+log:
+  level: info
+rate_limit:
+  auth:
+    requests: 10
+    window: 1m
+`)
+	opts := LoadOptions{Path: path}
+
+	cfg, err := Load(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	manager := NewManager(cfg, opts)
+
+	var levels []string
+	Subscribe(manager, func(c *Config) string { return c.Log.Level }, func(level string) {
+		levels = append(levels, level)
+	})
+	var portChanges int
+	Subscribe(manager, func(c *Config) int { return c.Port }, func(int) { portChanges++ })
+
+	content := "secret: " + testSecret + `
+port: 9090
+log:
+  level: debug
+rate_limit:
+  auth:
+    requests: 5
+    window: 1m
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := manager.Reload(); err != nil {
+		t.Fatalf("unexpected reload error: %v", err)
+	}
+
+	current := manager.Current()
+	if current.Log.Level != "debug" {
+		t.Errorf("expected log level to be reloaded, got %s", current.Log.Level)
+	}
+	if current.RateLimit.Auth.Requests != 5 {
+		t.Errorf("expected rate limit to be reloaded, got %d", current.RateLimit.Auth.Requests)
+	}
+	if current.Port != 8080 {
+		t.Errorf("expected port to require a restart, got %d", current.Port)
+	}
+	if len(levels) != 1 || levels[0] != "debug" {
+		t.Errorf("expected one log level notification, got %v", levels)
+	}
+	if portChanges != 0 {
+		t.Errorf("expected no port notification, got %d", portChanges)
+	}
+	if cfg.Log.Level != "info" {
+		t.Errorf("expected previous config to stay untouched, got %s", cfg.Log.Level)
+	}
+}
+
+func TestManager_ReloadInvalid(t *testing.T) {
+	path := writeConfig(t, "log:\n  level: info\n")
+	opts := LoadOptions{Path: path}
+
+	cfg, err := Load(opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	manager := NewManager(cfg, opts)
+
+	if err := os.WriteFile(path, []byte("log:\n  level: loud\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := manager.Reload(); err == nil {
+		t.Error("expected reload of an invalid config to fail")
+	}
+	if manager.Current() != cfg {
+		t.Error("expected the previous config to stay in effect")
+	}
+}
+
+func TestManager_WatchFailureIgnoresSIGHUP(t *testing.T) {
+	opts := LoadOptions{Path: filepath.Join(t.TempDir(), "missing", "config.yaml")}
+	manager := NewManager(Default(), opts)
+
+	if err := manager.Watch(context.Background()); err == nil {
+		t.Fatal("expected watching a missing directory to fail")
+	}
+
+	// With the default action restored this would kill the test binary.
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("failed to send SIGHUP: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Secret is a configuration value that must not end up in logs, error
+// messages or printed configuration. In the config file it is either the
+// value itself or a reference such as file:///run/secrets/db_password or
+// env:DB_PASSWORD that is resolved through a SecretProvider on load.
+type Secret struct {
+	raw   string
+	value string
+	// literal is set for connection strings whose scheme is also a
+	// provider scheme, such as SQLite's file:app.db, so they are not
+	// resolved.
+	literal bool
+}
+
+// NewSecret wraps a literal secret value.
+func NewSecret(value string) Secret {
+	return Secret{raw: value, value: value}
+}
+
+// Value returns the resolved secret.
+func (s Secret) Value() string {
+	return s.value
+}
+
+// IsReference reports whether the configured text points at a secret
+// provider instead of holding the value itself.
+func (s Secret) IsReference() bool {
+	if s.literal {
+		return false
+	}
+	_, _, ok := lookupSecretProvider(s.raw)
+	return ok
+}
+
+func (s Secret) String() string {
+	if s.value == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return fmt.Sprintf("config.Secret(%q)", s.String())
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
+
+// MarshalText keeps references, which are safe to show, and redacts
+// literal values.
+func (s Secret) MarshalText() ([]byte, error) {
+	if s.IsReference() {
+		return []byte(s.raw), nil
+	}
+	return []byte(s.String()), nil
+}
+
+func (s *Secret) UnmarshalText(text []byte) error {
+	*s = NewSecret(string(text))
+	return nil
+}
+
+// dsnSchemes are the URL schemes of connection strings. A Secret holding a
+// connection string is taken literally when it starts with one of them.
+var dsnSchemes = []string{"file", "postgres", "postgresql", "mysql", "sqlite"}
+
+// resolve replaces a reference with the value its provider returns. When
+// dsn is set the secret is a connection string, and one with a DSN scheme
+// is kept as is.
+func (s *Secret) resolve(ctx context.Context, dsn bool) error {
+	if dsn {
+		scheme, _, _ := strings.Cut(s.raw, ":")
+		if slices.Contains(dsnSchemes, scheme) {
+			s.literal = true
+			return nil
+		}
+	}
+
+	provider, ref, ok := lookupSecretProvider(s.raw)
+	if !ok {
+		return nil
+	}
+
+	value, err := provider.Resolve(ctx, ref)
+	if err != nil {
+		return err
+	}
+	s.value = value
+	return nil
+}
+
+// SecretProvider looks up secrets referenced from the configuration. Teams
+// can plug in a vault or cloud secret manager with RegisterSecretProvider.
+// Implementations must not include secret values in the errors they return.
+type SecretProvider interface {
+	// Resolve returns the secret for ref, the part of the reference after
+	// the scheme, e.g. /run/secrets/db_password for file:///run/secrets/db_password.
+	Resolve(ctx context.Context, ref string) (string, error)
+}
+
+var (
+	secretProvidersMu sync.RWMutex
+	secretProviders   = map[string]SecretProvider{
+		"file": FileSecretProvider{},
+		"env":  EnvSecretProvider{},
+	}
+)
+
+// RegisterSecretProvider makes provider handle references of the form
+// scheme:ref. Registering an existing scheme replaces its provider.
+func RegisterSecretProvider(scheme string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+
+	secretProviders[scheme] = provider
+}
+
+func lookupSecretProvider(raw string) (SecretProvider, string, bool) {
+	scheme, ref, ok := strings.Cut(raw, ":")
+	if !ok || scheme == "" {
+		return nil, "", false
+	}
+
+	secretProvidersMu.RLock()
+	provider, ok := secretProviders[scheme]
+	secretProvidersMu.RUnlock()
+	if !ok {
+		return nil, "", false
+	}
+
+	return provider, strings.TrimPrefix(ref, "//"), true
+}
+
+// FileSecretProvider reads secrets from files, as mounted by Docker and
+// Kubernetes secrets. A single trailing newline is stripped.
+type FileSecretProvider struct{}
+
+func (FileSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	value := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// EnvSecretProvider reads secrets from environment variables.
+type EnvSecretProvider struct{}
+
+func (EnvSecretProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+	return value, nil
+}
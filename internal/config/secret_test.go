@@ -0,0 +1,135 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestLoad_ResolvesSecretReferences(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(secretFile, []byte("file-password\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+	t.Setenv("TEST_JWT_SECRET", testSecret)
+
+	path := writeConfig(t, "database:\n  password: file://"+secretFile+"\n")
+	t.Setenv("TEST_SECRET", "env:TEST_JWT_SECRET")
+
+	cfg, err := Load(LoadOptions{Path: path, EnvPrefix: "TEST"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Database.Password.Value() != "file-password" {
+		t.Errorf("expected password from file, got %q", cfg.Database.Password.Value())
+	}
+	if cfg.Secret.Value() != testSecret {
+		t.Errorf("expected secret from env reference, got %q", cfg.Secret.Value())
+	}
+}
+
+func TestLoad_MissingSecretFile(t *testing.T) {
+	path := writeConfig(t, "database:\n  password: file:///does/not/exist\n")
+
+	_, err := Load(LoadOptions{Path: path})
+	if err == nil || !strings.Contains(err.Error(), "database.password") {
+		t.Errorf("expected error naming the secret, got %v", err)
+	}
+}
+
+func TestLoad_URLWithDSNSchemeIsNotAReference(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatalf("failed to change directory: %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	// A file named like the DSN must not be read as a secret.
+	if err := os.WriteFile("app.db?cache=shared", []byte("not a dsn"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	t.Setenv("TEST_DATABASE_URL_SECRET", "postgres://app@db/app")
+
+	path := writeConfig(t, "database:\n  type: sqlite\n  url: file:app.db?cache=shared\n")
+	cfg, err := Load(LoadOptions{Path: path})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Database.URL.Value(); got != "file:app.db?cache=shared" {
+		t.Errorf("expected the DSN to be kept, got %q", got)
+	}
+	if cfg.Database.URL.IsReference() {
+		t.Error("expected the DSN not to be reported as a reference")
+	}
+
+	path = writeConfig(t, "database:\n  url: env:TEST_DATABASE_URL_SECRET\n")
+	if cfg, err = Load(LoadOptions{Path: path}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.Database.URL.Value(); got != "postgres://app@db/app" {
+		t.Errorf("expected the URL from the env reference, got %q", got)
+	}
+}
+
+type staticProvider map[string]string
+
+func (p staticProvider) Resolve(ctx context.Context, ref string) (string, error) {
+	value, ok := p[ref]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return value, nil
+}
+
+func TestRegisterSecretProvider(t *testing.T) {
+	RegisterSecretProvider("vault", staticProvider{"kv/api/jwt": testSecret})
+
+	secret := NewSecret("vault:kv/api/jwt")
+	if err := secret.resolve(context.Background(), false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if secret.Value() != testSecret {
+		t.Errorf("expected value from custom provider, got %q", secret.Value())
+	}
+}
+
+func TestSecret_IsNotLeaked(t *testing.T) {
+	secret := NewSecret("hunter2")
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("config", "password", secret)
+
+	out, err := yaml.Marshal(map[string]Secret{"password": secret})
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	for name, rendered := range map[string]string{
+		"fmt %v":  fmt.Sprintf("%v", secret),
+		"fmt %+v": fmt.Sprintf("%+v", secret),
+		"fmt %#v": fmt.Sprintf("%#v", secret),
+		"slog":    logs.String(),
+		"yaml":    string(out),
+	} {
+		if strings.Contains(rendered, "hunter2") {
+			t.Errorf("%s leaked the secret: %s", name, rendered)
+		}
+	}
+
+	reference := NewSecret("file:///run/secrets/db_password")
+	out, _ = yaml.Marshal(map[string]Secret{"password": reference})
+	if !strings.Contains(string(out), "file:///run/secrets/db_password") {
+		t.Errorf("expected references to be printed as configured, got %s", out)
+	}
+}
@@ -0,0 +1,414 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minSecretLength is the HS256 key size; shorter keys weaken the signature.
+const minSecretLength = 32
+
+// ValidationError lists every problem found in a configuration so they can
+// be fixed in one go.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (e *ValidationError) addf(format string, args ...any) {
+	e.Problems = append(e.Problems, fmt.Sprintf(format, args...))
+}
+
+// merge adds the problems reported by a section, prefixed with its key.
+func (e *ValidationError) merge(section string, err error) {
+	if err == nil {
+		return
+	}
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		e.addf("%s: %v", section, err)
+		return
+	}
+	for _, problem := range verr.Problems {
+		if section != "" {
+			problem = section + "." + problem
+		}
+		e.Problems = append(e.Problems, problem)
+	}
+}
+
+func (e *ValidationError) orNil() error {
+	if len(e.Problems) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (c *Config) Validate() error {
+	verr := &ValidationError{}
+
+	switch c.Mode {
+	case ModeTypeDebug, ModeTypeTest, ModeTypeReleae:
+	default:
+		verr.addf("mode: unknown mode %q, expected debug, test or release", c.Mode)
+	}
+
+	if !validPort(c.Port) {
+		verr.addf("port: %d is out of range 1-65535", c.Port)
+	}
+
+	switch secret := c.Secret.Value(); {
+	case secret == "":
+		verr.addf("secret: is required to sign tokens")
+	case len(secret) < minSecretLength:
+		verr.addf("secret: must be at least %d bytes for HS256, got %d", minSecretLength, len(secret))
+	}
+
+	verr.merge("log", c.Log.Validate())
+	verr.merge("cors", c.CORS.Validate())
+	verr.merge("database", c.Database.Validate())
+	verr.merge("server", c.Server.Validate())
+	verr.merge("health", c.Health.Validate())
+	verr.merge("metrics", c.Metrics.Validate())
+	verr.merge("tracing", c.Tracing.Validate())
+	verr.merge("rate_limit", c.RateLimit.Validate())
+	verr.merge("idempotency", c.Idempotency.Validate())
+	verr.merge("migration", c.Migration.Validate())
+
+	return verr.orNil()
+}
+
+func (c LogConfig) Validate() error {
+	verr := &ValidationError{}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Level)); err != nil {
+		verr.addf("level: unknown level %q, expected debug, info, warn or error", c.Level)
+	}
+	switch c.Format {
+	case "text", "json":
+	default:
+		verr.addf("format: unknown format %q, expected text or json", c.Format)
+	}
+
+	return verr.orNil()
+}
+
+// Validate rejects a wildcard origin with credentials, which would let any
+// site make authenticated requests on behalf of the user.
+func (c CORSConfig) Validate() error {
+	verr := &ValidationError{}
+
+	if c.AllowCredentials && slices.Contains(c.AllowedOrigins, "*") {
+		verr.addf("allow_credentials: cannot be combined with the \"*\" origin, list the allowed origins instead")
+	}
+
+	return verr.orNil()
+}
+
+func (c DatabaseConfig) Validate() error {
+	verr := &ValidationError{}
+
+	embedded, known := lookupDatabaseType(c.Type)
+	switch {
+	case c.Type == "":
+		verr.addf("type: is required")
+	case !known:
+		verr.addf("type: unsupported database type %q, expected one of %s", c.Type, strings.Join(DatabaseTypes(), ", "))
+	case embedded:
+		if c.TLS != (TLSConfig{}) {
+			verr.addf("tls: is not supported for %s", c.Type)
+		}
+	case c.URL.Value() == "":
+		if c.Host == "" {
+			verr.addf("host: is required for %s", c.Type)
+		}
+		if !validPort(c.Port) {
+			verr.addf("port: %d is out of range 1-65535", c.Port)
+		}
+		if c.DbName == "" {
+			verr.addf("dbname: is required for %s", c.Type)
+		}
+	}
+
+	switch c.SSLMode {
+	case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		verr.addf("sslmode: unknown mode %q, expected disable, allow, prefer, require, verify-ca or verify-full", c.SSLMode)
+	}
+	if c.TLS != (TLSConfig{}) && (c.SSLMode == "" || c.SSLMode == "disable") {
+		verr.addf("tls: requires an sslmode other than disable")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		verr.addf("tls: cert_file and key_file must be set together")
+	}
+	if c.URL.Value() != "" {
+		if len(c.Options) > 0 {
+			verr.addf("options: cannot be combined with url")
+		}
+		if c.TLS != (TLSConfig{}) {
+			verr.addf("tls: cannot be combined with url")
+		}
+		if len(c.Replicas.Hosts) > 0 {
+			verr.addf("replicas: require host and port instead of url")
+		}
+	}
+
+	if c.MaxOpenConns < 0 {
+		verr.addf("max_open_conns: must not be negative")
+	}
+	if c.MaxOpenConns == 1 && !embedded {
+		verr.addf("max_open_conns: must be 0 (unlimited) or at least 2, the migration lock holds a connection of its own")
+	}
+	if c.MaxIdleConns < 0 {
+		verr.addf("max_idle_conns: must not be negative")
+	}
+	if c.MaxOpenConns > 0 && c.MaxIdleConns > c.MaxOpenConns {
+		verr.addf("max_idle_conns: %d exceeds max_open_conns %d", c.MaxIdleConns, c.MaxOpenConns)
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"conn_max_lifetime", c.ConnMaxLifetime},
+		{"conn_max_idle_time", c.ConnMaxIdleTime},
+		{"connect_timeout", c.ConnectTimeout},
+		{"statement_timeout", c.StatementTimeout},
+	} {
+		if timeout.value < 0 {
+			verr.addf("%s: must not be negative", timeout.name)
+		}
+	}
+	verr.merge("retry", c.Retry.Validate())
+	verr.merge("tx_retry", c.TxRetry.Validate())
+	if len(c.Replicas.Hosts) > 0 && embedded {
+		verr.addf("replicas: are not supported for %s", c.Type)
+	}
+	verr.merge("replicas", c.Replicas.Validate())
+	verr.merge("log", c.Log.Validate())
+
+	return verr.orNil()
+}
+
+func (c DatabaseLogConfig) Validate() error {
+	verr := &ValidationError{}
+
+	switch c.Level {
+	case "silent", "error", "warn", "info":
+	default:
+		verr.addf("level: unknown level %q, expected silent, error, warn or info", c.Level)
+	}
+	if c.SlowThreshold < 0 {
+		verr.addf("slow_threshold: must not be negative")
+	}
+
+	return verr.orNil()
+}
+
+func (c ReplicasConfig) Validate() error {
+	verr := &ValidationError{}
+
+	for _, host := range c.Hosts {
+		if host == "" {
+			verr.addf("hosts: must not contain empty entries")
+			continue
+		}
+		if !strings.Contains(host, ":") {
+			continue
+		}
+		_, port, err := net.SplitHostPort(host)
+		if err != nil {
+			verr.addf("hosts: invalid address %q: %v", host, err)
+			continue
+		}
+		if p, err := strconv.Atoi(port); err != nil || !validPort(p) {
+			verr.addf("hosts: port %q of %q is out of range 1-65535", port, host)
+		}
+	}
+	switch c.Policy {
+	case "round_robin", "random":
+	default:
+		verr.addf("policy: unknown policy %q, expected round_robin or random", c.Policy)
+	}
+	if c.HealthCheckInterval <= 0 {
+		verr.addf("health_check_interval: must be positive")
+	}
+
+	return verr.orNil()
+}
+
+func (c RetryConfig) Validate() error {
+	verr := &ValidationError{}
+
+	if c.Attempts < 1 {
+		verr.addf("attempts: must be at least 1")
+	}
+	if c.InitialBackoff < 0 {
+		verr.addf("initial_backoff: must not be negative")
+	}
+	if c.MaxBackoff < c.InitialBackoff {
+		verr.addf("max_backoff: must not be less than initial_backoff")
+	}
+
+	return verr.orNil()
+}
+
+func (c ServerConfig) Validate() error {
+	verr := &ValidationError{}
+
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read_timeout", c.ReadTimeout},
+		{"read_header_timeout", c.ReadHeaderTimeout},
+		{"write_timeout", c.WriteTimeout},
+		{"idle_timeout", c.IdleTimeout},
+		{"drain_delay", c.DrainDelay},
+	} {
+		if timeout.value < 0 {
+			verr.addf("%s: must not be negative", timeout.name)
+		}
+	}
+	if c.ShutdownTimeout <= 0 {
+		verr.addf("shutdown_timeout: must be positive")
+	}
+	if c.MaxHeaderBytes < 0 {
+		verr.addf("max_header_bytes: must not be negative")
+	}
+	for _, proxy := range c.TrustedProxies {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				verr.addf("trusted_proxies: %q is not an IP address or CIDR", proxy)
+			}
+		}
+	}
+
+	return verr.orNil()
+}
+
+func (c HealthConfig) Validate() error {
+	verr := &ValidationError{}
+
+	if c.CacheTTL < 0 {
+		verr.addf("cache_ttl: must not be negative")
+	}
+	if c.CheckTimeout <= 0 {
+		verr.addf("check_timeout: must be positive")
+	}
+
+	return verr.orNil()
+}
+
+func (c MetricsConfig) Validate() error {
+	verr := &ValidationError{}
+
+	if c.Enabled && !strings.HasPrefix(c.Path, "/") {
+		verr.addf("path: must start with /, got %q", c.Path)
+	}
+
+	return verr.orNil()
+}
+
+func (c TracingConfig) Validate() error {
+	verr := &ValidationError{}
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Exporter {
+	case "otlp":
+		if c.Endpoint == "" {
+			verr.addf("endpoint: is required for the otlp exporter")
+		}
+	case "stdout":
+	default:
+		verr.addf("exporter: unsupported exporter %q, expected otlp or stdout", c.Exporter)
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		verr.addf("sample_ratio: must be between 0 and 1, got %v", c.SampleRatio)
+	}
+	if c.ServiceName == "" {
+		verr.addf("service_name: is required")
+	}
+
+	return verr.orNil()
+}
+
+func (c RateLimitConfig) Validate() error {
+	verr := &ValidationError{}
+
+	if !validStore(c.Store) {
+		verr.addf("store: unsupported store %q, expected memory or sql", c.Store)
+	}
+	verr.merge("global", c.Global.Validate())
+	verr.merge("auth", c.Auth.Validate())
+	verr.merge("api", c.API.Validate())
+
+	return verr.orNil()
+}
+
+func (r RateLimitRule) Validate() error {
+	verr := &ValidationError{}
+
+	if r.Requests < 0 {
+		verr.addf("requests: must not be negative")
+	}
+	if r.Requests > 0 && r.Window <= 0 {
+		verr.addf("window: must be positive when requests is set")
+	}
+	switch r.Key {
+	case "", "ip", "user", "api_key":
+	default:
+		verr.addf("key: unsupported key %q, expected ip, user or api_key", r.Key)
+	}
+
+	return verr.orNil()
+}
+
+func (c IdempotencyConfig) Validate() error {
+	verr := &ValidationError{}
+
+	if !validStore(c.Store) {
+		verr.addf("store: unsupported store %q, expected memory or sql", c.Store)
+	}
+	if c.Enabled && c.TTL <= 0 {
+		verr.addf("ttl: must be positive")
+	}
+	if c.Enabled && c.Lease <= 0 {
+		verr.addf("lease: must be positive")
+	}
+	if c.Enabled && c.Lease > c.TTL {
+		verr.addf("lease: %s exceeds ttl %s", c.Lease, c.TTL)
+	}
+
+	return verr.orNil()
+}
+
+func (c MigrationConfig) Validate() error {
+	verr := &ValidationError{}
+
+	if c.LockTimeout <= 0 {
+		verr.addf("lock_timeout: must be positive")
+	}
+
+	return verr.orNil()
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validStore(store string) bool {
+	return store == "memory" || store == "sql"
+}
@@ -4,22 +4,93 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shuv1824/go-api-starter/internal/common/emailaddr"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser  = "user"
+	RoleStaff = "staff"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID        uuid.UUID      `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	Email     string         `gorm:"uniqueIndex;not null" json:"email"`
-	Password  string         `gorm:"not null" json:"-"`
+	ID        uuid.UUID      `gorm:"primaryKey;size:36;index:idx_users_created_at,priority:2" json:"id"`
+	Email     string         `gorm:"size:128;not null" json:"email"`
+	EmailKey  string         `gorm:"size:128;uniqueIndex;not null" json:"-"`
+	Password  string         `gorm:"size:255;not null" json:"-"`
 	Name      string         `gorm:"not null" json:"name"`
-	IsActive  bool           `gorm:"default:true" json:"is_active"`
-	CreatedAt time.Time      `json:"created_at"`
+	IsActive  bool           `gorm:"not null" json:"is_active"`
+	Role      string         `gorm:"size:16;not null;default:user" json:"role"`
+	Version   int64          `gorm:"not null;default:1" json:"-"`
+	CreatedAt time.Time      `gorm:"not null;index:idx_users_created_at,priority:1" json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
+// BeforeCreate assigns the ID in Go, since not every supported database can
+// generate UUIDs itself, starts the version at 1 and makes users plain
+// users unless given a role.
+func (u *User) BeforeCreate(tx *gorm.DB) error {
+	if u.ID == uuid.Nil {
+		u.ID = uuid.New()
+	}
+	if u.Version == 0 {
+		u.Version = 1
+	}
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+	return nil
+}
+
+// BeforeSave derives EmailKey, which identifies the account: addresses
+// differing only in case or Unicode form share it.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.EmailKey = emailaddr.Key(u.Email)
+	return nil
+}
+
+// IsStaff reports whether the user may see other users' accounts.
+func (u *User) IsStaff() bool {
+	return u.Role == RoleStaff || u.Role == RoleAdmin
+}
+
+// UnmanagedIndexes are the search indexes, which GORM cannot declare; see
+// the add_user_search migration.
+func (User) UnmanagedIndexes() []string {
+	return []string{"idx_users_search", "idx_users_search_trgm"}
+}
+
+// ListSpec is what user listings can be sorted and filtered by. Both sorts
+// are backed by an index that is unique or ends in the ID. Emails sort by
+// their key, ignoring case.
+var ListSpec = query.Spec{
+	Sorts: map[string]query.Field{
+		"created_at": {Column: "created_at", Parse: query.Time},
+		"email":      {Column: "email_key", Parse: query.String},
+	},
+	DefaultSort: "-created_at",
+	Filters: map[string]query.Filter{
+		"email_prefix":   {Field: query.Field{Column: "email_key", Parse: emailKey}, Op: query.Prefix},
+		"is_active":      {Field: query.Field{Column: "is_active", Parse: query.Bool}, Op: query.Equal},
+		"created_after":  {Field: query.Field{Column: "created_at", Parse: query.Time}, Op: query.GreaterOrEqual},
+		"created_before": {Field: query.Field{Column: "created_at", Parse: query.Time}, Op: query.Less},
+	},
+	Key:          query.Field{Column: "id", Parse: query.String},
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+// emailKey folds an email prefix like a whole address, so it matches keys
+// regardless of case.
+func emailKey(s string) (any, error) {
+	return emailaddr.Key(s), nil
+}
+
 type UpdateUserRequest struct {
-	Name string `json:"name"`
+	Name string `json:"name" binding:"required"`
 }
 
 type CreateUserRequest struct {
@@ -28,6 +99,21 @@ type CreateUserRequest struct {
 	Name     string `json:"name" binding:"required"`
 }
 
+// UpsertUserRequest creates a user, or updates the user with the same email,
+// so loading it repeatedly leaves a single, current user.
+type UpsertUserRequest struct {
+	CreateUserRequest
+	IsActive bool `json:"is_active"`
+	// Role defaults to RoleUser.
+	Role string `json:"role" binding:"omitempty,oneof=user staff admin"`
+}
+
+// SearchUsersRequest finds users by partial name or email.
+type SearchUsersRequest struct {
+	Query string `form:"q" binding:"required,min=2,max=100"`
+	Limit int    `form:"limit,default=20" binding:"min=1,max=100"`
+}
+
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
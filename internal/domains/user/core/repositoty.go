@@ -4,14 +4,26 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+	// GetByEmail ignores the case of email, as the unique index on emails
+	// does.
 	GetByEmail(ctx context.Context, email string) (*User, error)
+	// Update writes user if its row still has user.Version and increments
+	// the version; otherwise it fails with ErrVersionConflict.
 	Update(ctx context.Context, user *User) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, limit, offset int) ([]*User, error)
+	// Delete removes the user if its row still has version.
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
+	List(ctx context.Context, params query.Params) (query.Page[*User], error)
 	Count(ctx context.Context) (int64, error)
 }
+
+// UserSearcher finds users by partial name or email, best matches first.
+// Implementations use the full-text search of the database in use.
+type UserSearcher interface {
+	Search(ctx context.Context, term string, limit int) ([]*User, error)
+}
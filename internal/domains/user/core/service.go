@@ -4,10 +4,18 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
 )
 
 type Service interface {
 	Register(ctx context.Context, req CreateUserRequest) (*AuthResponse, error)
 	Login(ctx context.Context, req LoginRequest) (*AuthResponse, error)
 	GetByID(ctx context.Context, id uuid.UUID) (*User, error)
+	List(ctx context.Context, params query.Params) (query.Page[*User], error)
+	Search(ctx context.Context, req SearchUsersRequest) ([]*User, error)
+	Upsert(ctx context.Context, req UpsertUserRequest) (user *User, created bool, err error)
+	// Update and Delete fail with ErrVersionConflict when the user changed
+	// since the caller read version.
+	Update(ctx context.Context, id uuid.UUID, version int64, req UpdateUserRequest) (*User, error)
+	Delete(ctx context.Context, id uuid.UUID, version int64) error
 }
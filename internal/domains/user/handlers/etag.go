@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+)
+
+// etag formats a user version as a strong entity tag.
+func etag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// parseIfMatch returns the versions listed in an If-Match header, and
+// whether it is "*". Weak tags never match under the strong comparison
+// If-Match requires, and tags not issued by etag cannot match either, so
+// both are dropped.
+func parseIfMatch(header string) (versions []int64, anyVersion bool) {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return nil, true
+		}
+		unquoted, ok := strings.CutPrefix(tag, `"`)
+		if !ok {
+			continue
+		}
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+		if !ok {
+			continue
+		}
+		if version, err := strconv.ParseInt(unquoted, 10, 64); err == nil && version > 0 {
+			versions = append(versions, version)
+		}
+	}
+	return versions, false
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header       string
+		wantVersions []int64
+		wantAny      bool
+	}{
+		{header: `"3"`, wantVersions: []int64{3}},
+		{header: `"3", "4"`, wantVersions: []int64{3, 4}},
+		{header: "*", wantAny: true},
+		{header: `W/"3"`},
+		{header: `3`},
+		{header: `"abc"`},
+		{header: `"0"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			versions, anyVersion := parseIfMatch(tt.header)
+			if !slices.Equal(versions, tt.wantVersions) || anyVersion != tt.wantAny {
+				t.Errorf("parseIfMatch(%q) = %v, %v, want %v, %v", tt.header, versions, anyVersion, tt.wantVersions, tt.wantAny)
+			}
+		})
+	}
+
+	if got := etag(7); got != `"7"` {
+		t.Errorf("etag(7) = %s", got)
+	}
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	stderrors "errors"
 	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/shuv1824/go-api-starter/internal/common/auth"
 	"github.com/shuv1824/go-api-starter/internal/common/errors"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
 	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
 )
 
@@ -53,39 +56,185 @@ func (h *Handler) Login(c *gin.Context) {
 }
 
 func (h *Handler) GetProfile(c *gin.Context) {
-	claims, exists := c.Get("claims")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userId, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
-	currentUser := claims.(*auth.Claims)
-
-	userId, err := uuid.Parse(currentUser.UserID)
+	user, err := h.userService.GetByID(c.Request.Context(), userId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failded to parse user id"})
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(user.Version))
+	c.JSON(http.StatusOK, user)
+}
+
+// RequireStaff lets only staff and admins through. The role is read from
+// the database rather than the token, so revoking it takes effect at once.
+func (h *Handler) RequireStaff(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		c.Abort()
 		return
 	}
 
 	user, err := h.userService.GetByID(c.Request.Context(), userId)
 	if err != nil {
+		if stderrors.Is(err, errors.ErrNotFound) {
+			err = errors.ErrUnauthorized
+		}
 		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+	if !user.IsStaff() {
+		h.handleError(c, errors.ErrForbidden)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+// ListUsers returns a page of users; see core.ListSpec for the sort and
+// filter parameters.
+func (h *Handler) ListUsers(c *gin.Context) {
+	params, err := core.ListSpec.Parse(c.Request.URL.Query())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.userService.List(c.Request.Context(), params)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// SearchUsers returns the users best matching the q parameter, without
+// further pages.
+func (h *Handler) SearchUsers(c *gin.Context) {
+	var req core.SearchUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	users, err := h.userService.Search(c.Request.Context(), req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, query.Page[*core.User]{Items: users})
+}
+
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
 		return
 	}
 
+	var req core.UpdateUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c, userId)
+	if !ok {
+		return
+	}
+
+	user, err := h.userService.Update(c.Request.Context(), userId, version, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(user.Version))
 	c.JSON(http.StatusOK, user)
 }
 
+func (h *Handler) DeleteProfile(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	version, ok := h.ifMatchVersion(c, userId)
+	if !ok {
+		return
+	}
+
+	if err := h.userService.Delete(c.Request.Context(), userId, version); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return uuid.Nil, false
+	}
+
+	currentUser := claims.(*auth.Claims)
+
+	userId, err := uuid.Parse(currentUser.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failded to parse user id"})
+		return uuid.Nil, false
+	}
+	return userId, true
+}
+
+// ifMatchVersion returns the version the request's If-Match header requires
+// the user to have. A single tag is passed on for the repository to check
+// atomically; "*" and lists are resolved against the current version.
+func (h *Handler) ifMatchVersion(c *gin.Context, userId uuid.UUID) (int64, bool) {
+	versions, anyVersion := parseIfMatch(c.GetHeader("If-Match"))
+	if !anyVersion && len(versions) == 1 {
+		return versions[0], true
+	}
+
+	if anyVersion || len(versions) > 1 {
+		user, err := h.userService.GetByID(c.Request.Context(), userId)
+		if err != nil {
+			h.handleError(c, err)
+			return 0, false
+		}
+		if anyVersion || slices.Contains(versions, user.Version) {
+			return user.Version, true
+		}
+	}
+
+	h.handleError(c, errors.ErrVersionConflict)
+	return 0, false
+}
+
 func (h *Handler) handleError(c *gin.Context, err error) {
-	switch err {
-	case errors.ErrNotFound:
+	switch {
+	case stderrors.Is(err, errors.ErrNotFound):
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
-	case errors.ErrEmailExists:
+	case stderrors.Is(err, errors.ErrEmailExists):
 		c.JSON(http.StatusConflict, gin.H{"error": "Email already exists"})
-	case errors.ErrInvalidPassword:
+	case stderrors.Is(err, errors.ErrVersionConflict):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "User was modified, fetch it again and retry"})
+	case stderrors.Is(err, errors.ErrInvalidPassword):
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
-	case errors.ErrUnauthorized:
+	case stderrors.Is(err, errors.ErrUnauthorized):
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case stderrors.Is(err, errors.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
 	default:
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
 	}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shuv1824/go-api-starter/internal/common/auth"
+	"github.com/shuv1824/go-api-starter/internal/common/errors"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
+	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
+)
+
+// fakeService serves users by ID; the embedded interface panics for methods
+// a test did not expect to be called.
+type fakeService struct {
+	core.Service
+	users map[uuid.UUID]*core.User
+}
+
+func (f *fakeService) GetByID(ctx context.Context, id uuid.UUID) (*core.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, fmt.Errorf("get user %s: %w", id, errors.ErrNotFound)
+	}
+	return user, nil
+}
+
+func (f *fakeService) List(ctx context.Context, params query.Params) (query.Page[*core.User], error) {
+	return query.Page[*core.User]{Items: []*core.User{}}, nil
+}
+
+func (f *fakeService) Search(ctx context.Context, req core.SearchUsersRequest) ([]*core.User, error) {
+	return []*core.User{}, nil
+}
+
+func TestRequireStaff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &fakeService{users: map[uuid.UUID]*core.User{}}
+	for _, role := range []string{core.RoleUser, core.RoleStaff, core.RoleAdmin} {
+		user := &core.User{ID: uuid.New(), Role: role}
+		service.users[user.ID] = user
+	}
+	idByRole := func(role string) string {
+		for id, user := range service.users {
+			if user.Role == role {
+				return id.String()
+			}
+		}
+		return ""
+	}
+
+	h := NewHandler(service)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("claims", &auth.Claims{UserID: c.GetHeader("X-Test-User")})
+	})
+	router.GET("/users", h.RequireStaff, h.ListUsers)
+	router.GET("/users/search", h.RequireStaff, h.SearchUsers)
+
+	tests := []struct {
+		name   string
+		userID string
+		want   int
+	}{
+		{"user", idByRole(core.RoleUser), http.StatusForbidden},
+		{"staff", idByRole(core.RoleStaff), http.StatusOK},
+		{"admin", idByRole(core.RoleAdmin), http.StatusOK},
+		{"deleted user", uuid.New().String(), http.StatusUnauthorized},
+	}
+
+	for _, path := range []string{"/users", "/users/search?q=ann"} {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				req.Header.Set("X-Test-User", tt.userID)
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+
+				if w.Code != tt.want {
+					t.Errorf("expected status %d, got %d: %s", tt.want, w.Code, w.Body)
+				}
+			})
+		}
+	}
+}
+
+func TestHandleError_Wrapped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("create user: %w", errors.ErrEmailExists), http.StatusConflict},
+		{fmt.Errorf("update user: %w", errors.ErrVersionConflict), http.StatusPreconditionFailed},
+		{fmt.Errorf("load user: %w", errors.ErrNotFound), http.StatusNotFound},
+		{fmt.Errorf("unexpected"), http.StatusInternalServerError},
+	}
+
+	h := NewHandler(&fakeService{})
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		h.handleError(c, tt.err)
+		if w.Code != tt.want {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.want, w.Code)
+		}
+	}
+}
@@ -3,9 +3,13 @@ package infra
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shuv1824/go-api-starter/internal/common/emailaddr"
 	apperrors "github.com/shuv1824/go-api-starter/internal/common/errors"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
+	"github.com/shuv1824/go-api-starter/internal/common/tx"
 	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
 	"gorm.io/gorm"
 )
@@ -19,12 +23,12 @@ func NewRepository(db *gorm.DB) *UserRepository {
 }
 
 func (r *UserRepository) Create(ctx context.Context, user *core.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return r.translate(tx.DB(ctx, r.db).Create(user).Error)
 }
 
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*core.User, error) {
 	var user core.User
-	err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error
+	err := tx.DB(ctx, r.db).Where("id = ?", id).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, apperrors.ErrNotFound
@@ -36,7 +40,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*core.User,
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*core.User, error) {
 	var user core.User
-	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	err := tx.DB(ctx, r.db).Where("email_key = ?", emailaddr.Key(email)).First(&user).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, apperrors.ErrNotFound
@@ -47,21 +51,75 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*core.Us
 }
 
 func (r *UserRepository) Update(ctx context.Context, user *core.User) error {
-	return r.db.WithContext(ctx).Save(user).Error
+	// user is only changed once the write succeeded, so a caller can
+	// retry with it after a conflict. Every update changes the version, so
+	// the affected rows also count matched rows on MySQL, which reports
+	// changed rows only.
+	now := time.Now()
+	key := emailaddr.Key(user.Email)
+	result := tx.DB(ctx, r.db).Model(&core.User{}).
+		Where("id = ? AND version = ?", user.ID, user.Version).
+		Updates(map[string]any{
+			"email":      user.Email,
+			"email_key":  key,
+			"password":   user.Password,
+			"name":       user.Name,
+			"is_active":  user.IsActive,
+			"role":       user.Role,
+			"version":    user.Version + 1,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return r.translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return r.conflict(ctx, user.ID)
+	}
+	user.EmailKey = key
+	user.Version++
+	user.UpdatedAt = now
+	return nil
+}
+
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	result := tx.DB(ctx, r.db).Where("id = ? AND version = ?", id, version).Delete(&core.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return r.conflict(ctx, id)
+	}
+	return nil
 }
 
-func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&core.User{}, "id = ?", id).Error
+// translate reports a unique violation as ErrEmailExists: IDs are random,
+// so the email key is the only unique column a write can collide on. The
+// index covers soft-deleted users too, so their emails stay reserved.
+func (r *UserRepository) translate(err error) error {
+	if translator, ok := r.db.Dialector.(gorm.ErrorTranslator); ok && err != nil {
+		err = translator.Translate(err)
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return apperrors.ErrEmailExists
+	}
+	return err
+}
+
+// conflict explains why a conditional write matched no row: the user is
+// gone, or it has a newer version.
+func (r *UserRepository) conflict(ctx context.Context, id uuid.UUID) error {
+	if _, err := r.GetByID(ctx, id); err != nil {
+		return err
+	}
+	return apperrors.ErrVersionConflict
 }
 
-func (r *UserRepository) List(ctx context.Context, limit, offset int) ([]*core.User, error) {
-	var users []*core.User
-	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&users).Error
-	return users, err
+func (r *UserRepository) List(ctx context.Context, params query.Params) (query.Page[*core.User], error) {
+	return query.Find[*core.User](tx.DB(ctx, r.db).Model(&core.User{}), params)
 }
 
 func (r *UserRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&core.User{}).Count(&count).Error
+	err := tx.DB(ctx, r.db).Model(&core.User{}).Count(&count).Error
 	return count, err
 }
@@ -3,39 +3,68 @@ package infra
 import (
 	"context"
 	"fmt"
-	"os"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/shuv1824/go-api-starter/internal/config"
 	"github.com/shuv1824/go-api-starter/internal/migration"
+	"github.com/shuv1824/go-api-starter/internal/migration/migrationtest"
 	"github.com/shuv1824/go-api-starter/pkg/database"
 	apperrors "github.com/shuv1824/go-api-starter/internal/common/errors"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
 	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
 	"gorm.io/gorm"
 )
 
-func setupTestDB(t *testing.T) *gorm.DB {
+// testDatabaseTypes returns the databases the repository tests run against:
+// SQLite in-process, plus the database configured in config.test.yaml.
+func testDatabaseTypes(cfg *config.Config) []string {
+	types := []string{"sqlite"}
+	if cfg != nil && cfg.Database.Type != "sqlite" {
+		types = append(types, cfg.Database.Type)
+	}
+	return types
+}
+
+// forEachDatabase runs fn once per test database, each time against a fresh,
+// migrated database.
+func forEachDatabase(t *testing.T, fn func(t *testing.T, db *gorm.DB)) {
 	// Load test configuration
 	cfg, err := config.InitConfig("../../../../config.test.yaml")
 	if err != nil {
-		t.Skipf("Skipping test: could not load test config: %v", err)
+		t.Logf("Warning: Test config not found, only testing SQLite: %v", err)
+		cfg = nil
+	}
+
+	for _, dbType := range testDatabaseTypes(cfg) {
+		t.Run(dbType, func(t *testing.T) {
+			fn(t, setupTestDB(t, cfg, dbType))
+		})
+	}
+}
+
+func setupTestDB(t *testing.T, cfg *config.Config, dbType string) *gorm.DB {
+	if dbType == "sqlite" {
+		return setupSQLiteTestDB(t)
 	}
 
 	// Create unique test database name to avoid conflicts
 	testDBName := fmt.Sprintf("%s_%s", cfg.Database.DbName, uuid.New().String()[:8])
 
-	// First connect to postgres database to create test database
-	pgCfg := cfg.Database
-	pgCfg.DbName = "postgres" // Connect to default postgres db first
+	// First connect to the server's default database to create test database
+	adminCfg := cfg.Database
+	adminCfg.DbName = adminDatabase(dbType)
 
-	pgDB, err := database.NewDatabase(&pgCfg)
+	adminDB, err := database.NewDatabase(&adminCfg)
 	if err != nil {
-		t.Skipf("Skipping test: could not connect to postgres: %v", err)
+		t.Skipf("Skipping test: could not connect to %s: %v", dbType, err)
 	}
 
 	// Create test database
-	sqlDB, err := pgDB.DB()
+	sqlDB, err := adminDB.DB()
 	if err != nil {
 		t.Fatalf("failed to get database instance: %v", err)
 	}
@@ -56,7 +85,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 
 	// Run migrations
-	if err := migration.MigrateUp(db, cfg.Database.Type); err != nil {
+	if err := migration.MigrateUp(db, dbType, 0); err != nil {
 		t.Fatalf("failed to migrate test database: %v", err)
 	}
 
@@ -68,10 +97,10 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			sqlDB.Close()
 		}
 
-		// Connect to postgres db to drop test database
-		pgDB, err := database.NewDatabase(&pgCfg)
+		// Connect to the default database to drop test database
+		adminDB, err := database.NewDatabase(&adminCfg)
 		if err == nil {
-			sqlDB, err := pgDB.DB()
+			sqlDB, err := adminDB.DB()
 			if err == nil {
 				sqlDB.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS %s", testDBName))
 				sqlDB.Close()
@@ -82,30 +111,44 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	return db
 }
 
-func TestMain(m *testing.M) {
-	// Check if PostgreSQL is available for testing
-	cfg, err := config.InitConfig("../../../../config.test.yaml")
+// setupSQLiteTestDB returns a migrated in-memory database private to t.
+func setupSQLiteTestDB(t *testing.T) *gorm.DB {
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = ":memory:"
+
+	db, err := database.NewDatabase(&cfg)
 	if err != nil {
-		fmt.Printf("Warning: Test config not found, skipping database tests: %v\n", err)
-		os.Exit(0)
+		t.Fatalf("failed to open SQLite database: %v", err)
 	}
 
-	// Try to connect to test database to ensure it's available
-	pgCfg := cfg.Database
-	pgCfg.DbName = "postgres" // Connect to default postgres DB first
-	
-	_, err = database.NewDatabase(&pgCfg)
-	if err != nil {
-		fmt.Printf("Warning: PostgreSQL not available for testing, skipping database tests: %v\n", err)
-		os.Exit(0)
+	if err := migration.MigrateUp(db, cfg.Type, 0); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
 	}
 
-	// Run tests
-	os.Exit(m.Run())
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+// adminDatabase is the database connected to while creating and dropping
+// test databases.
+func adminDatabase(dbType string) string {
+	if dbType == "postgres" {
+		return "postgres"
+	}
+	return ""
 }
 
 func TestUserRepository_Create(t *testing.T) {
-	db := setupTestDB(t)
+	forEachDatabase(t, testUserRepositoryCreate)
+}
+
+func testUserRepositoryCreate(t *testing.T, db *gorm.DB) {
 	repo := NewRepository(db)
 
 	user := &core.User{
@@ -134,7 +177,10 @@ func TestUserRepository_Create(t *testing.T) {
 }
 
 func TestUserRepository_GetByID(t *testing.T) {
-	db := setupTestDB(t)
+	forEachDatabase(t, testUserRepositoryGetByID)
+}
+
+func testUserRepositoryGetByID(t *testing.T, db *gorm.DB) {
 	repo := NewRepository(db)
 
 	// Create a test user
@@ -202,7 +248,10 @@ func TestUserRepository_GetByID(t *testing.T) {
 }
 
 func TestUserRepository_GetByEmail(t *testing.T) {
-	db := setupTestDB(t)
+	forEachDatabase(t, testUserRepositoryGetByEmail)
+}
+
+func testUserRepositoryGetByEmail(t *testing.T, db *gorm.DB) {
 	repo := NewRepository(db)
 
 	// Create a test user
@@ -229,6 +278,11 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 			email:       user.Email,
 			expectError: false,
 		},
+		{
+			name:        "email in another case",
+			email:       "Test@EXAMPLE.com",
+			expectError: false,
+		},
 		{
 			name:        "non-existing user",
 			email:       "nonexistent@example.com",
@@ -269,8 +323,53 @@ func TestUserRepository_GetByEmail(t *testing.T) {
 	}
 }
 
+func TestUserRepository_EmailUniqueIgnoringCase(t *testing.T) {
+	forEachDatabase(t, testUserRepositoryEmailUniqueIgnoringCase)
+}
+
+func testUserRepositoryEmailUniqueIgnoringCase(t *testing.T, db *gorm.DB) {
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	user := &core.User{Email: "Case@example.com", Password: "hashedpassword", Name: "Case"}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	accented := &core.User{Email: "Jos\u00e9@example.com", Password: "hashedpassword", Name: "Jose"}
+	if err := repo.Create(ctx, accented); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if found, err := repo.GetByEmail(ctx, "JOS\u00c9@EXAMPLE.com"); err != nil || found.ID != accented.ID {
+		t.Errorf("expected lookup folding non-ASCII case to find the user, got %v", err)
+	}
+
+	for _, email := range []string{"cASE@example.com", "jose\u0301@example.com"} {
+		other := &core.User{Email: email, Password: "hashedpassword", Name: "Other"}
+		if err := repo.Create(ctx, other); err != apperrors.ErrEmailExists {
+			t.Errorf("%q: expected an email differing only in case or form to be rejected, got %v", email, err)
+		}
+	}
+
+	accented.Email = "CASE@example.com"
+	if err := repo.Update(ctx, accented); err != apperrors.ErrEmailExists {
+		t.Errorf("expected changing to a taken email to be rejected, got %v", err)
+	}
+
+	if err := repo.Delete(ctx, user.ID, user.Version); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+	again := &core.User{Email: "case@example.com", Password: "hashedpassword", Name: "Again"}
+	if err := repo.Create(ctx, again); err != apperrors.ErrEmailExists {
+		t.Errorf("expected the email of a deleted user to stay reserved, got %v", err)
+	}
+}
+
 func TestUserRepository_Update(t *testing.T) {
-	db := setupTestDB(t)
+	forEachDatabase(t, testUserRepositoryUpdate)
+}
+
+func testUserRepositoryUpdate(t *testing.T, db *gorm.DB) {
 	repo := NewRepository(db)
 
 	// Create a test user
@@ -309,10 +408,75 @@ func TestUserRepository_Update(t *testing.T) {
 	if dbUser.IsActive != false {
 		t.Errorf("expected IsActive to be false, got %v", dbUser.IsActive)
 	}
+
+	if user.Version != 2 || dbUser.Version != 2 {
+		t.Errorf("expected version 2, got %d in memory and %d stored", user.Version, dbUser.Version)
+	}
+}
+
+func TestUserRepository_VersionConflict(t *testing.T) {
+	forEachDatabase(t, testUserRepositoryVersionConflict)
+}
+
+func testUserRepositoryVersionConflict(t *testing.T, db *gorm.DB) {
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	user := &core.User{
+		ID:       uuid.New(),
+		Email:    "test@example.com",
+		Password: "hashedpassword",
+		Name:     "Test User",
+		IsActive: true,
+	}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	// Two writers read the same version; the second one is stale.
+	first, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to read user: %v", err)
+	}
+	second, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to read user: %v", err)
+	}
+
+	first.Name = "First"
+	if err := repo.Update(ctx, first); err != nil {
+		t.Fatalf("unexpected error updating user: %v", err)
+	}
+
+	second.Name = "Second"
+	if err := repo.Update(ctx, second); err != apperrors.ErrVersionConflict {
+		t.Errorf("expected version conflict on update, got %v", err)
+	}
+	if err := repo.Delete(ctx, user.ID, second.Version); err != apperrors.ErrVersionConflict {
+		t.Errorf("expected version conflict on delete, got %v", err)
+	}
+
+	stored, err := repo.GetByID(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to read user: %v", err)
+	}
+	if stored.Name != "First" {
+		t.Errorf("expected the first update to be kept, got %s", stored.Name)
+	}
+
+	if err := repo.Delete(ctx, user.ID, stored.Version); err != nil {
+		t.Fatalf("unexpected error deleting user: %v", err)
+	}
+	if err := repo.Delete(ctx, user.ID, stored.Version); err != apperrors.ErrNotFound {
+		t.Errorf("expected deleting a deleted user to fail with not found, got %v", err)
+	}
 }
 
 func TestUserRepository_Delete(t *testing.T) {
-	db := setupTestDB(t)
+	forEachDatabase(t, testUserRepositoryDelete)
+}
+
+func testUserRepositoryDelete(t *testing.T, db *gorm.DB) {
 	repo := NewRepository(db)
 
 	// Create a test user
@@ -329,7 +493,7 @@ func TestUserRepository_Delete(t *testing.T) {
 	}
 
 	// Delete the user
-	err := repo.Delete(context.Background(), user.ID)
+	err := repo.Delete(context.Background(), user.ID, user.Version)
 	if err != nil {
 		t.Errorf("unexpected error deleting user: %v", err)
 	}
@@ -352,7 +516,10 @@ func TestUserRepository_Delete(t *testing.T) {
 }
 
 func TestUserRepository_List(t *testing.T) {
-	db := setupTestDB(t)
+	forEachDatabase(t, testUserRepositoryList)
+}
+
+func testUserRepositoryList(t *testing.T, db *gorm.DB) {
 	repo := NewRepository(db)
 
 	// Create test users
@@ -366,7 +533,7 @@ func TestUserRepository_List(t *testing.T) {
 		},
 		{
 			ID:       uuid.New(),
-			Email:    "user2@example.com",
+			Email:    "User2@Example.com",
 			Password: "hashedpassword",
 			Name:     "User 2",
 			IsActive: true,
@@ -380,56 +547,61 @@ func TestUserRepository_List(t *testing.T) {
 		},
 	}
 
-	for _, user := range users {
+	// Distinct creation times make the default newest-first order certain.
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for i, user := range users {
+		user.CreatedAt = created.Add(time.Duration(i) * time.Minute)
 		if err := db.Create(user).Error; err != nil {
 			t.Fatalf("failed to create test user: %v", err)
 		}
 	}
 
-	tests := []struct {
-		name          string
-		limit         int
-		offset        int
-		expectedCount int
-	}{
-		{
-			name:          "get all users",
-			limit:         10,
-			offset:        0,
-			expectedCount: 3,
-		},
-		{
-			name:          "get first 2 users",
-			limit:         2,
-			offset:        0,
-			expectedCount: 2,
-		},
-		{
-			name:          "get users with offset",
-			limit:         10,
-			offset:        1,
-			expectedCount: 2,
-		},
+	list := func(values url.Values) query.Page[*core.User] {
+		t.Helper()
+		params, err := core.ListSpec.Parse(values)
+		if err != nil {
+			t.Fatalf("failed to parse %v: %v", values, err)
+		}
+		page, err := repo.List(context.Background(), params)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return page
+	}
+	names := func(page query.Page[*core.User]) string {
+		var names []string
+		for _, user := range page.Items {
+			names = append(names, user.Name)
+		}
+		return strings.Join(names, ", ")
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result, err := repo.List(context.Background(), tt.limit, tt.offset)
+	first := list(url.Values{"limit": {"2"}})
+	if got := names(first); got != "User 3, User 2" {
+		t.Errorf("expected the newest users first, got %s", got)
+	}
+	if first.NextCursor == "" || first.PrevCursor != "" {
+		t.Fatalf("expected only a next cursor on the first page, got %+v", first)
+	}
 
-			if err != nil {
-				t.Errorf("unexpected error: %v", err)
-				return
-			}
+	second := list(url.Values{"cursor": {first.NextCursor}, "limit": {"2"}})
+	if got := names(second); got != "User 1" {
+		t.Errorf("expected the remaining user on the second page, got %s", got)
+	}
+	if second.NextCursor != "" || second.PrevCursor == "" {
+		t.Errorf("expected only a prev cursor on the last page, got %+v", second)
+	}
 
-			if len(result) != tt.expectedCount {
-				t.Errorf("expected %d users, got %d", tt.expectedCount, len(result))
-			}
-		})
+	filtered := list(url.Values{"email_prefix": {"USER2@example"}, "sort": {"email"}})
+	if got := names(filtered); got != "User 2" {
+		t.Errorf("expected the email prefix to select User 2 ignoring case, got %s", got)
 	}
 }
-
 func TestUserRepository_Count(t *testing.T) {
-	db := setupTestDB(t)
+	forEachDatabase(t, testUserRepositoryCount)
+}
+
+func testUserRepositoryCount(t *testing.T, db *gorm.DB) {
 	repo := NewRepository(db)
 
 	// Initially should be 0
@@ -452,7 +624,7 @@ func TestUserRepository_Count(t *testing.T) {
 		},
 		{
 			ID:       uuid.New(),
-			Email:    "user2@example.com",
+			Email:    "User2@Example.com",
 			Password: "hashedpassword",
 			Name:     "User 2",
 			IsActive: true,
@@ -473,3 +645,9 @@ func TestUserRepository_Count(t *testing.T) {
 		t.Errorf("expected count 2, got %d", count)
 	}
 }
+
+func TestUserRepository_Schema(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, db *gorm.DB) {
+		migrationtest.AssertNoDrift(t, db, &core.User{})
+	})
+}
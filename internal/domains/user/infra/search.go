@@ -0,0 +1,173 @@
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/shuv1824/go-api-starter/internal/common/query"
+	"github.com/shuv1824/go-api-starter/internal/common/tx"
+	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NewSearcher returns the user search for the dialect of dbType, which
+// relies on the indexes of the add_user_search migration.
+func NewSearcher(db *gorm.DB, dbType string) (core.UserSearcher, error) {
+	driver, err := database.Lookup(dbType)
+	if err != nil {
+		return nil, err
+	}
+	switch driver.Dialect {
+	case "postgres":
+		return &postgresSearcher{db: db}, nil
+	case "mysql":
+		var minTokenSize int
+		if err := db.Raw("SELECT @@innodb_ft_min_token_size").Scan(&minTokenSize).Error; err != nil {
+			return nil, fmt.Errorf("failed to read innodb_ft_min_token_size: %w", err)
+		}
+		return &mysqlSearcher{db: db, minTokenSize: minTokenSize}, nil
+	case "sqlite":
+		fts, err := hasSQLiteSearchTable(db)
+		if err != nil {
+			return nil, err
+		}
+		return &sqliteSearcher{db: db, fts: fts}, nil
+	}
+	return nil, fmt.Errorf("user search does not support dialect %q", driver.Dialect)
+}
+
+// hasSQLiteSearchTable reports whether the migration created the FTS5
+// search table, which it skips when SQLite lacks FTS5. A binary without
+// FTS5 cannot use a table created by one with it, nor write users through
+// its triggers.
+func hasSQLiteSearchTable(db *gorm.DB) (bool, error) {
+	if !db.Migrator().HasTable("users_search") {
+		slog.Warn("users_search table missing, user search falls back to pattern matching")
+		return false, nil
+	}
+
+	var fts5 bool
+	if err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5).Error; err != nil {
+		return false, err
+	}
+	if !fts5 {
+		return false, errors.New("the users_search table needs SQLite with FTS5, build with -tags sqlite_fts5")
+	}
+	return true, nil
+}
+
+// searchWords splits term into lower-case words of letters and digits,
+// dropping the punctuation every database gives its own meaning in search
+// syntax.
+func searchWords(term string) []string {
+	return strings.FieldsFunc(strings.ToLower(term), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func orderBy(sql string, vars ...any) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}}
+}
+
+// postgresSearcher matches word prefixes with the tsvector index and
+// fragments anywhere in the name or email with the trigram index.
+type postgresSearcher struct {
+	db *gorm.DB
+}
+
+func (s *postgresSearcher) Search(ctx context.Context, term string, limit int) ([]*core.User, error) {
+	words := searchWords(term)
+	if len(words) == 0 {
+		return []*core.User{}, nil
+	}
+	tsquery := strings.Join(words, ":* & ") + ":*"
+	pattern := "%" + query.EscapeLike(term) + "%"
+
+	// The expressions match the index definitions so the indexes are used.
+	var users []*core.User
+	err := tx.DB(ctx, s.db).
+		Where("(to_tsvector('simple', name || ' ' || email) @@ to_tsquery('simple', ?) OR (name || ' ' || email) ILIKE ? ESCAPE '!')", tsquery, pattern).
+		Clauses(orderBy("ts_rank(to_tsvector('simple', name || ' ' || email), to_tsquery('simple', ?)) + similarity(name || ' ' || email, ?) DESC, id", tsquery, term)).
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// mysqlSearcher matches word prefixes with the FULLTEXT index. Fragments
+// inside words are not found. Words shorter than innodb_ft_min_token_size
+// are not indexed, so terms containing them are matched by pattern instead.
+type mysqlSearcher struct {
+	db           *gorm.DB
+	minTokenSize int
+}
+
+func (s *mysqlSearcher) Search(ctx context.Context, term string, limit int) ([]*core.User, error) {
+	words := searchWords(term)
+	if len(words) == 0 {
+		return []*core.User{}, nil
+	}
+	for _, word := range words {
+		if utf8.RuneCountInString(word) < s.minTokenSize {
+			return likeSearch(tx.DB(ctx, s.db), term, limit)
+		}
+	}
+	against := "+" + strings.Join(words, "* +") + "*"
+
+	var users []*core.User
+	err := tx.DB(ctx, s.db).
+		Where("MATCH (name, email) AGAINST (? IN BOOLEAN MODE)", against).
+		Clauses(orderBy("MATCH (name, email) AGAINST (? IN BOOLEAN MODE) DESC, id", against)).
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// sqliteSearcher matches word prefixes with the FTS5 table, or falls back
+// to pattern matching when SQLite lacks FTS5.
+type sqliteSearcher struct {
+	db  *gorm.DB
+	fts bool
+}
+
+func (s *sqliteSearcher) Search(ctx context.Context, term string, limit int) ([]*core.User, error) {
+	words := searchWords(term)
+	if len(words) == 0 {
+		return []*core.User{}, nil
+	}
+	db := tx.DB(ctx, s.db)
+	if !s.fts {
+		return likeSearch(db, term, limit)
+	}
+
+	// Quoted words are literal, so the term cannot inject FTS5 syntax.
+	match := `"` + strings.Join(words, `"* "`) + `"*`
+	var users []*core.User
+	err := db.
+		Joins("JOIN users_search ON users_search.id = users.id").
+		Where("users_search MATCH ?", match).
+		Clauses(orderBy("bm25(users_search), users.id")).
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// likeSearch scans for the term anywhere in the name or email and ranks
+// prefix matches first.
+func likeSearch(db *gorm.DB, term string, limit int) ([]*core.User, error) {
+	pattern := "%" + query.EscapeLike(term) + "%"
+	prefix := query.EscapeLike(term) + "%"
+	var users []*core.User
+	err := db.
+		Where("(name LIKE ? ESCAPE '!' OR email LIKE ? ESCAPE '!')", pattern, pattern).
+		Clauses(orderBy("CASE WHEN name LIKE ? ESCAPE '!' OR email LIKE ? ESCAPE '!' THEN 0 ELSE 1 END, name, id", prefix, prefix)).
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
@@ -0,0 +1,85 @@
+package infra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
+	"gorm.io/gorm"
+)
+
+func TestSearcher(t *testing.T) {
+	forEachDatabase(t, testSearcher)
+}
+
+func testSearcher(t *testing.T, db *gorm.DB) {
+	repo := NewRepository(db)
+	ctx := context.Background()
+
+	searcher, err := NewSearcher(db, db.Dialector.Name())
+	if err != nil {
+		t.Fatalf("failed to create searcher: %v", err)
+	}
+
+	users := map[string]*core.User{}
+	for _, u := range []struct{ name, email string }{
+		{"Ann Smith", "ann@example.com"},
+		{"Bob Annan", "bob@example.com"},
+		{"Carol White", "carol@example.org"},
+		{"Deleted Ann", "deleted.ann@example.com"},
+	} {
+		user := &core.User{ID: uuid.New(), Name: u.name, Email: u.email, Password: "hashedpassword", IsActive: true}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		users[u.name] = user
+	}
+	deleted := users["Deleted Ann"]
+	if err := repo.Delete(ctx, deleted.ID, deleted.Version); err != nil {
+		t.Fatalf("failed to delete user: %v", err)
+	}
+
+	search := func(term string) []string {
+		t.Helper()
+		found, err := searcher.Search(ctx, term, 10)
+		if err != nil {
+			t.Fatalf("search for %q failed: %v", term, err)
+		}
+		var names []string
+		for _, user := range found {
+			names = append(names, user.Name)
+		}
+		return names
+	}
+
+	names := search("ann")
+	if len(names) < 2 || names[0] != "Ann Smith" {
+		t.Errorf("expected Ann Smith ranked first, got %v", names)
+	}
+	for _, name := range names {
+		if name == "Deleted Ann" {
+			t.Error("expected deleted users to be excluded")
+		}
+		if name == "Carol White" {
+			t.Error("expected non-matching users to be excluded")
+		}
+	}
+
+	if names := search("carol@example"); len(names) != 1 || names[0] != "Carol White" {
+		t.Errorf("expected the email to find Carol White, got %v", names)
+	}
+
+	carol := users["Carol White"]
+	carol.Name = "Carol Black"
+	if err := repo.Update(ctx, carol); err != nil {
+		t.Fatalf("failed to update user: %v", err)
+	}
+	if names := search("black"); len(names) != 1 || names[0] != "Carol Black" {
+		t.Errorf("expected the renamed user to be found, got %v", names)
+	}
+
+	if names := search("%_!"); len(names) != 0 {
+		t.Errorf("expected punctuation alone to match nothing, got %v", names)
+	}
+}
@@ -6,6 +6,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shuv1824/go-api-starter/internal/common/auth"
+	"github.com/shuv1824/go-api-starter/internal/common/emailaddr"
+	"github.com/shuv1824/go-api-starter/internal/common/metrics"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
+	"github.com/shuv1824/go-api-starter/internal/common/tx"
 	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
 	"golang.org/x/crypto/bcrypt"
 
@@ -14,44 +18,56 @@ import (
 
 type service struct {
 	repo       core.UserRepository
+	searcher   core.UserSearcher
+	tx         tx.Manager
 	jwtService *auth.Service
+	emails     emailaddr.Normalizer
 }
 
-func NewService(repo core.UserRepository, jwtService *auth.Service) *service {
+func NewService(repo core.UserRepository, searcher core.UserSearcher, txManager tx.Manager, jwtService *auth.Service, emails emailaddr.Normalizer) *service {
 	return &service{
 		repo:       repo,
+		searcher:   searcher,
+		tx:         txManager,
 		jwtService: jwtService,
+		emails:     emails,
 	}
 }
 
-func (s *service) Register(ctx context.Context, req core.CreateUserRequest) (*core.AuthResponse, error) {
-	// Check if user already exists
-	existingUser, err := s.repo.GetByEmail(ctx, req.Email)
-	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
-		return nil, err
-	}
-	if existingUser != nil {
-		return nil, apperrors.ErrEmailExists
-	}
+func (s *service) Register(ctx context.Context, req core.CreateUserRequest) (resp *core.AuthResponse, err error) {
+	defer func() { metrics.RecordRegistration(registrationOutcome(err)) }()
+
+	email := s.emails.Normalize(req.Email)
 
-	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	// Hash password before the transaction, so bcrypt does not hold it open
+	// and retries do not hash again.
+	hashedPassword, err := hashPassword(ctx, req.Password)
 	if err != nil {
 		return nil, err
 	}
 
-	id := uuid.New()
-
-	// Create user
 	user := &core.User{
-		ID:       id,
-		Email:    req.Email,
+		ID:       uuid.New(),
+		Email:    email,
 		Password: string(hashedPassword),
 		Name:     req.Name,
 		IsActive: true,
 	}
 
-	if err := s.repo.Create(ctx, user); err != nil {
+	err = s.tx.Run(ctx, func(ctx context.Context) error {
+		// Check if user already exists
+		existingUser, err := s.repo.GetByEmail(ctx, email)
+		if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+			return err
+		}
+		if existingUser != nil {
+			return apperrors.ErrEmailExists
+		}
+
+		// Create user
+		return s.repo.Create(ctx, user)
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -67,8 +83,10 @@ func (s *service) Register(ctx context.Context, req core.CreateUserRequest) (*co
 	}, nil
 }
 
-func (s *service) Login(ctx context.Context, req core.LoginRequest) (*core.AuthResponse, error) {
-	user, err := s.repo.GetByEmail(ctx, req.Email)
+func (s *service) Login(ctx context.Context, req core.LoginRequest) (resp *core.AuthResponse, err error) {
+	defer func() { metrics.RecordLogin(loginOutcome(err)) }()
+
+	user, err := s.repo.GetByEmail(ctx, s.emails.Normalize(req.Email))
 	if err != nil {
 		if errors.Is(err, apperrors.ErrNotFound) {
 			return nil, apperrors.ErrInvalidPassword
@@ -81,7 +99,7 @@ func (s *service) Login(ctx context.Context, req core.LoginRequest) (*core.AuthR
 	}
 
 	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	if err := comparePassword(ctx, user.Password, req.Password); err != nil {
 		return nil, apperrors.ErrInvalidPassword
 	}
 
@@ -100,3 +118,152 @@ func (s *service) Login(ctx context.Context, req core.LoginRequest) (*core.AuthR
 func (s *service) GetByID(ctx context.Context, id uuid.UUID) (*core.User, error) {
 	return s.repo.GetByID(ctx, id)
 }
+
+func (s *service) List(ctx context.Context, params query.Params) (query.Page[*core.User], error) {
+	return s.repo.List(ctx, params)
+}
+
+func (s *service) Search(ctx context.Context, req core.SearchUsersRequest) ([]*core.User, error) {
+	return s.searcher.Search(ctx, req.Query, req.Limit)
+}
+
+// Upsert reads and writes the user in one transaction, which also keeps the
+// lookup on the primary: a replica lagging behind an earlier upsert would
+// report the user as missing and the create would fail on the unique email.
+func (s *service) Upsert(ctx context.Context, req core.UpsertUserRequest) (user *core.User, created bool, err error) {
+	email := s.emails.Normalize(req.Email)
+	password, err := s.passwordHash(ctx, email, req.Password)
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = s.tx.Run(ctx, func(ctx context.Context) error {
+		user, created, err = s.upsert(ctx, email, password, req)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return user, created, nil
+}
+
+// passwordHash runs bcrypt before the upsert transaction, so it does not hold
+// the transaction open and retries do not hash again. It returns the stored
+// hash when it already matches password, so unchanged users are not
+// rewritten with a new salt on every run. The lookup may be stale, which only
+// costs a rewrite: either hash verifies password.
+func (s *service) passwordHash(ctx context.Context, email, password string) (string, error) {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return "", err
+	}
+	if user != nil && comparePassword(ctx, user.Password, password) == nil {
+		return user.Password, nil
+	}
+
+	hashedPassword, err := hashPassword(ctx, password)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+func (s *service) upsert(ctx context.Context, email, password string, req core.UpsertUserRequest) (*core.User, bool, error) {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, apperrors.ErrNotFound) {
+		return nil, false, err
+	}
+	role := req.Role
+	if role == "" {
+		role = core.RoleUser
+	}
+
+	if user == nil {
+		user = &core.User{
+			Email:    email,
+			Password: password,
+			Name:     req.Name,
+			IsActive: req.IsActive,
+			Role:     role,
+		}
+		if err := s.repo.Create(ctx, user); err != nil {
+			return nil, false, err
+		}
+		return user, true, nil
+	}
+
+	changed := user.Name != req.Name || user.IsActive != req.IsActive || user.Role != role || user.Password != password
+	user.Name = req.Name
+	user.IsActive = req.IsActive
+	user.Role = role
+	user.Password = password
+
+	if changed {
+		if err := s.repo.Update(ctx, user); err != nil {
+			return nil, false, err
+		}
+	}
+	return user, false, nil
+}
+
+func (s *service) Update(ctx context.Context, id uuid.UUID, version int64, req core.UpdateUserRequest) (*core.User, error) {
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// Checked early for a clear error; the repository enforces it again
+	// against writes made after the read.
+	if user.Version != version {
+		return nil, apperrors.ErrVersionConflict
+	}
+
+	user.Name = req.Name
+	if err := s.repo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *service) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	return s.repo.Delete(ctx, id, version)
+}
+
+// hashPassword and comparePassword get their own spans because bcrypt is
+// deliberately slow and usually dominates register and login latency.
+func hashPassword(ctx context.Context, password string) ([]byte, error) {
+	_, span := tracer.Start(ctx, "bcrypt.GenerateFromPassword")
+	defer span.End()
+
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+func comparePassword(ctx context.Context, hash, password string) error {
+	_, span := tracer.Start(ctx, "bcrypt.CompareHashAndPassword")
+	defer span.End()
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+func registrationOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case errors.Is(err, apperrors.ErrEmailExists):
+		return metrics.OutcomeConflict
+	default:
+		return metrics.OutcomeError
+	}
+}
+
+func loginOutcome(err error) string {
+	switch {
+	case err == nil:
+		return metrics.OutcomeSuccess
+	case errors.Is(err, apperrors.ErrInvalidPassword):
+		return metrics.OutcomeInvalidCredentials
+	case errors.Is(err, apperrors.ErrUnauthorized):
+		return metrics.OutcomeInactive
+	default:
+		return metrics.OutcomeError
+	}
+}
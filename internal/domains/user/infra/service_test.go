@@ -8,7 +8,10 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/shuv1824/go-api-starter/internal/common/auth"
+	"github.com/shuv1824/go-api-starter/internal/common/emailaddr"
 	apperrors "github.com/shuv1824/go-api-starter/internal/common/errors"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
+	"github.com/shuv1824/go-api-starter/internal/common/tx/txtest"
 	"github.com/shuv1824/go-api-starter/internal/config"
 	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
 	"golang.org/x/crypto/bcrypt"
@@ -16,8 +19,10 @@ import (
 
 // MockUserRepository implements core.UserRepository for testing
 type MockUserRepository struct {
-	users map[string]*core.User // key: email
+	users map[string]*core.User // key: emailaddr.Key, as the unique index
 	err   error
+	// writeErr fails only writes, so reads made before a transaction pass.
+	writeErr error
 }
 
 func NewMockUserRepository() *MockUserRepository {
@@ -30,7 +35,10 @@ func (m *MockUserRepository) Create(ctx context.Context, user *core.User) error
 	if m.err != nil {
 		return m.err
 	}
-	m.users[user.Email] = user
+	if m.writeErr != nil {
+		return m.writeErr
+	}
+	m.users[emailaddr.Key(user.Email)] = user
 	return nil
 }
 
@@ -50,7 +58,7 @@ func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*cor
 	if m.err != nil {
 		return nil, m.err
 	}
-	user, exists := m.users[email]
+	user, exists := m.users[emailaddr.Key(email)]
 	if !exists {
 		return nil, apperrors.ErrNotFound
 	}
@@ -61,16 +69,23 @@ func (m *MockUserRepository) Update(ctx context.Context, user *core.User) error
 	if m.err != nil {
 		return m.err
 	}
-	m.users[user.Email] = user
+	if m.writeErr != nil {
+		return m.writeErr
+	}
+	user.Version++
+	m.users[emailaddr.Key(user.Email)] = user
 	return nil
 }
 
-func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID, version int64) error {
 	if m.err != nil {
 		return m.err
 	}
 	for email, user := range m.users {
 		if user.ID == id {
+			if user.Version != version {
+				return apperrors.ErrVersionConflict
+			}
 			delete(m.users, email)
 			return nil
 		}
@@ -78,15 +93,15 @@ func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return apperrors.ErrNotFound
 }
 
-func (m *MockUserRepository) List(ctx context.Context, limit, offset int) ([]*core.User, error) {
+func (m *MockUserRepository) List(ctx context.Context, params query.Params) (query.Page[*core.User], error) {
 	if m.err != nil {
-		return nil, m.err
+		return query.Page[*core.User]{}, m.err
 	}
 	var users []*core.User
 	for _, user := range m.users {
 		users = append(users, user)
 	}
-	return users, nil
+	return query.Page[*core.User]{Items: users}, nil
 }
 
 func (m *MockUserRepository) Count(ctx context.Context) (int64, error) {
@@ -100,20 +115,24 @@ func (m *MockUserRepository) SetError(err error) {
 	m.err = err
 }
 
+func (m *MockUserRepository) SetWriteError(err error) {
+	m.writeErr = err
+}
+
 func (m *MockUserRepository) AddUser(user *core.User) {
-	m.users[user.Email] = user
+	m.users[emailaddr.Key(user.Email)] = user
 }
 
 func setupTestService(t *testing.T) (*service, *MockUserRepository, *auth.Service) {
 	// Load test configuration
-	cfg, err := config.InitConfig("../../../../config.test.yaml")
-	if err != nil {
-		t.Skipf("Skipping test: could not load test config: %v", err)
+	secret := "test-secret-key-for-hs256-signing"
+	if cfg, err := config.InitConfig("../../../../config.test.yaml"); err == nil {
+		secret = cfg.Secret.Value()
 	}
 
 	mockRepo := NewMockUserRepository()
-	jwtService := auth.NewService(cfg.Secret, time.Hour, time.Hour*24)
-	service := NewService(mockRepo, jwtService)
+	jwtService := auth.NewService(secret, time.Hour, time.Hour*24)
+	service := NewService(mockRepo, nil, &txtest.Manager{}, jwtService, emailaddr.Normalizer{})
 
 	return service, mockRepo, jwtService
 }
@@ -159,6 +178,17 @@ func TestService_Register(t *testing.T) {
 			expectError: true,
 			errorType:   apperrors.ErrEmailExists,
 		},
+		{
+			name: "email already exists in another case",
+			request: core.CreateUserRequest{
+				Email:    "Existing@EXAMPLE.com",
+				Password: "password123",
+				Name:     "Test User",
+			},
+			setupMock:   func() { mockRepo.SetError(nil) },
+			expectError: true,
+			errorType:   apperrors.ErrEmailExists,
+		},
 		{
 			name: "repository error on GetByEmail",
 			request: core.CreateUserRequest{
@@ -338,3 +368,120 @@ func TestService_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestService_EmailNormalization(t *testing.T) {
+	service, _, _ := setupTestService(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name           string
+		lowercaseLocal bool
+		want           string
+	}{
+		{name: "local part kept", want: "Ann@example.com"},
+		{name: "local part lowercased", lowercaseLocal: true, want: "ann@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service.repo = NewMockUserRepository()
+			service.emails = emailaddr.Normalizer{LowercaseLocalPart: tt.lowercaseLocal}
+
+			resp, err := service.Register(ctx, core.CreateUserRequest{Email: " Ann@Example.COM ", Password: "password123", Name: "Ann"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if resp.User.Email != tt.want {
+				t.Errorf("expected email %q, got %q", tt.want, resp.User.Email)
+			}
+
+			_, err = service.Register(ctx, core.CreateUserRequest{Email: "ANN@example.com", Password: "password123", Name: "Ann"})
+			if !errors.Is(err, apperrors.ErrEmailExists) {
+				t.Errorf("expected email exists, got %v", err)
+			}
+
+			if _, err := service.Login(ctx, core.LoginRequest{Email: "aNN@EXAMPLE.com", Password: "password123"}); err != nil {
+				t.Errorf("expected login in another case to succeed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestService_UpsertTransaction(t *testing.T) {
+	service, mockRepo, _ := setupTestService(t)
+	txManager := service.tx.(*txtest.Manager)
+	ctx := context.Background()
+	req := core.UpsertUserRequest{
+		CreateUserRequest: core.CreateUserRequest{Email: "upsert@example.com", Password: "password123", Name: "Upsert"},
+		IsActive:          true,
+	}
+
+	if _, created, err := service.Upsert(ctx, req); err != nil || !created {
+		t.Fatalf("expected user to be created, got created=%v err=%v", created, err)
+	}
+	if txManager.Commits() != 1 {
+		t.Errorf("expected 1 commit, got %d", txManager.Commits())
+	}
+
+	mockRepo.SetWriteError(errors.New("database error"))
+	req.Name = "Renamed"
+	if _, _, err := service.Upsert(ctx, req); err == nil {
+		t.Fatal("expected error")
+	}
+	if txManager.Rollbacks() != 1 {
+		t.Errorf("expected 1 rollback, got %d", txManager.Rollbacks())
+	}
+}
+
+func TestService_RegisterTransaction(t *testing.T) {
+	service, mockRepo, _ := setupTestService(t)
+	txManager := service.tx.(*txtest.Manager)
+	ctx := context.Background()
+	req := core.CreateUserRequest{Email: "register@example.com", Password: "password123", Name: "Register"}
+
+	if _, err := service.Register(ctx, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if txManager.Commits() != 1 {
+		t.Errorf("expected 1 commit, got %d", txManager.Commits())
+	}
+
+	if _, err := service.Register(ctx, req); !errors.Is(err, apperrors.ErrEmailExists) {
+		t.Fatalf("expected email exists, got %v", err)
+	}
+	mockRepo.SetWriteError(errors.New("database error"))
+	req.Email = "other@example.com"
+	if _, err := service.Register(ctx, req); err == nil {
+		t.Fatal("expected error")
+	}
+	if txManager.Rollbacks() != 2 {
+		t.Errorf("expected 2 rollbacks, got %d", txManager.Rollbacks())
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	service, mockRepo, _ := setupTestService(t)
+	ctx := context.Background()
+
+	user := &core.User{ID: uuid.New(), Email: "update@example.com", Name: "Before", IsActive: true, Version: 3}
+	mockRepo.AddUser(user)
+
+	if _, err := service.Update(ctx, user.ID, 2, core.UpdateUserRequest{Name: "Stale"}); err != apperrors.ErrVersionConflict {
+		t.Errorf("expected version conflict, got %v", err)
+	}
+	if user.Name != "Before" {
+		t.Errorf("expected stale update to be rejected, got name %s", user.Name)
+	}
+
+	updated, err := service.Update(ctx, user.ID, 3, core.UpdateUserRequest{Name: "After"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.Name != "After" || updated.Version != 4 {
+		t.Errorf("expected name After at version 4, got %s at version %d", updated.Name, updated.Version)
+	}
+
+	if _, err := service.Update(ctx, uuid.New(), 1, core.UpdateUserRequest{Name: "Missing"}); err != apperrors.ErrNotFound {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
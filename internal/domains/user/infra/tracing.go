@@ -0,0 +1,114 @@
+package infra
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/shuv1824/go-api-starter/internal/common/query"
+	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/shuv1824/go-api-starter/internal/domains/user")
+
+// tracedService wraps a core.Service with a span per method.
+type tracedService struct {
+	next core.Service
+}
+
+func NewTracedService(next core.Service) core.Service {
+	return &tracedService{next: next}
+}
+
+func (s *tracedService) Register(ctx context.Context, req core.CreateUserRequest) (*core.AuthResponse, error) {
+	ctx, span := tracer.Start(ctx, "user.Service.Register")
+	defer span.End()
+
+	resp, err := s.next.Register(ctx, req)
+	recordSpanError(span, err)
+	return resp, err
+}
+
+func (s *tracedService) Login(ctx context.Context, req core.LoginRequest) (*core.AuthResponse, error) {
+	ctx, span := tracer.Start(ctx, "user.Service.Login")
+	defer span.End()
+
+	resp, err := s.next.Login(ctx, req)
+	recordSpanError(span, err)
+	return resp, err
+}
+
+func (s *tracedService) GetByID(ctx context.Context, id uuid.UUID) (*core.User, error) {
+	ctx, span := tracer.Start(ctx, "user.Service.GetByID", trace.WithAttributes(
+		attribute.String("user.id", id.String()),
+	))
+	defer span.End()
+
+	user, err := s.next.GetByID(ctx, id)
+	recordSpanError(span, err)
+	return user, err
+}
+
+func (s *tracedService) List(ctx context.Context, params query.Params) (query.Page[*core.User], error) {
+	ctx, span := tracer.Start(ctx, "user.Service.List", trace.WithAttributes(
+		attribute.String("query.sort", params.Sort),
+		attribute.Int("query.limit", params.Limit),
+	))
+	defer span.End()
+
+	page, err := s.next.List(ctx, params)
+	recordSpanError(span, err)
+	return page, err
+}
+
+func (s *tracedService) Search(ctx context.Context, req core.SearchUsersRequest) ([]*core.User, error) {
+	ctx, span := tracer.Start(ctx, "user.Service.Search", trace.WithAttributes(
+		attribute.Int("search.limit", req.Limit),
+	))
+	defer span.End()
+
+	users, err := s.next.Search(ctx, req)
+	recordSpanError(span, err)
+	return users, err
+}
+
+func (s *tracedService) Upsert(ctx context.Context, req core.UpsertUserRequest) (*core.User, bool, error) {
+	ctx, span := tracer.Start(ctx, "user.Service.Upsert")
+	defer span.End()
+
+	user, created, err := s.next.Upsert(ctx, req)
+	recordSpanError(span, err)
+	return user, created, err
+}
+
+func (s *tracedService) Update(ctx context.Context, id uuid.UUID, version int64, req core.UpdateUserRequest) (*core.User, error) {
+	ctx, span := tracer.Start(ctx, "user.Service.Update", trace.WithAttributes(
+		attribute.String("user.id", id.String()),
+	))
+	defer span.End()
+
+	user, err := s.next.Update(ctx, id, version, req)
+	recordSpanError(span, err)
+	return user, err
+}
+
+func (s *tracedService) Delete(ctx context.Context, id uuid.UUID, version int64) error {
+	ctx, span := tracer.Start(ctx, "user.Service.Delete", trace.WithAttributes(
+		attribute.String("user.id", id.String()),
+	))
+	defer span.End()
+
+	err := s.next.Delete(ctx, id, version)
+	recordSpanError(span, err)
+	return err
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
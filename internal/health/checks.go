@@ -0,0 +1,25 @@
+package health
+
+import (
+	"context"
+
+	"github.com/shuv1824/go-api-starter/internal/migration"
+	"gorm.io/gorm"
+)
+
+// DatabaseCheck pings the database through gorm's connection pool.
+func DatabaseCheck(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// MigrationCheck fails while the database schema is behind the migrations
+// embedded in the binary.
+func MigrationCheck(db *gorm.DB, dbType string) (CheckFunc, error) {
+	return migration.UpToDateCheck(db, dbType)
+}
@@ -0,0 +1,60 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LivezHandler serves the liveness probe.
+func (c *Checker) LivezHandler(ctx *gin.Context) {
+	writeReport(ctx, c.Live(ctx.Request.Context()))
+}
+
+// ReadyzHandler serves the readiness probe.
+func (c *Checker) ReadyzHandler(ctx *gin.Context) {
+	writeReport(ctx, c.Ready(ctx.Request.Context()))
+}
+
+// DetailsHandler serves the liveness and readiness reports with the error,
+// duration and cache state of every check. They can leak internals such as
+// hostnames and user names, so only mount it on the admin listener or behind
+// authentication.
+func (c *Checker) DetailsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		details := struct {
+			Live  Report `json:"live"`
+			Ready Report `json:"ready"`
+		}{
+			Live:  c.Live(r.Context()),
+			Ready: c.Ready(r.Context()),
+		}
+
+		status := http.StatusOK
+		if !details.Live.Healthy() || !details.Ready.Healthy() {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(details)
+	})
+}
+
+// writeReport answers with a bare "ok" or "fail" for probes, or with the
+// name and status of every check as JSON when the verbose query parameter is
+// set.
+func writeReport(c *gin.Context, report Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+
+	if _, verbose := c.GetQuery("verbose"); verbose {
+		c.JSON(status, report.Summary())
+		return
+	}
+
+	c.String(status, report.Status)
+}
@@ -0,0 +1,211 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+var ErrNotReady = errors.New("server is not ready")
+
+// CheckFunc reports the health of a single dependency.
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one check. Probes are unauthenticated, so
+// they only serve the name and status; the details are served by
+// DetailsHandler.
+type CheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	Cached   bool   `json:"cached,omitempty"`
+}
+
+// Report aggregates the results of a set of checks.
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Healthy reports whether every check in the report passed.
+func (r Report) Healthy() bool {
+	return r.Status == StatusOK
+}
+
+// Summary returns the report with only the name and status of each check.
+func (r Report) Summary() Report {
+	checks := make([]CheckResult, len(r.Checks))
+	for i, result := range r.Checks {
+		checks[i] = CheckResult{Name: result.Name, Status: result.Status}
+	}
+	return Report{Status: r.Status, Checks: checks}
+}
+
+type check struct {
+	name    string
+	fn      CheckFunc
+	timeout time.Duration
+
+	mu        sync.Mutex
+	last      CheckResult
+	checkedAt time.Time
+}
+
+// Checker runs registered checks for the liveness and readiness endpoints.
+// Results are cached for a short time so that frequent probes do not hammer
+// the dependencies they check.
+type Checker struct {
+	cacheTTL       time.Duration
+	defaultTimeout time.Duration
+	ready          atomic.Bool
+
+	mu        sync.RWMutex
+	liveness  []*check
+	readiness []*check
+}
+
+func NewChecker(cacheTTL, defaultTimeout time.Duration) *Checker {
+	return &Checker{
+		cacheTTL:       cacheTTL,
+		defaultTimeout: defaultTimeout,
+	}
+}
+
+// AddLivenessCheck registers a check that, when failing, means the process
+// should be restarted. Keep these free of external dependencies.
+func (c *Checker) AddLivenessCheck(name string, fn CheckFunc, timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.liveness = append(c.liveness, c.newCheck(name, fn, timeout))
+}
+
+// AddReadinessCheck registers a check that must pass before the instance
+// receives traffic.
+func (c *Checker) AddReadinessCheck(name string, fn CheckFunc, timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.readiness = append(c.readiness, c.newCheck(name, fn, timeout))
+}
+
+// SetReady marks whether the instance is able to serve traffic at all. It is
+// false until startup completes and is cleared again when draining.
+func (c *Checker) SetReady(ready bool) {
+	c.ready.Store(ready)
+}
+
+// Live runs the liveness checks.
+func (c *Checker) Live(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := c.liveness
+	c.mu.RUnlock()
+
+	return c.run(ctx, checks)
+}
+
+// Ready runs the readiness checks. It fails without running them while the
+// instance is starting up or shutting down.
+func (c *Checker) Ready(ctx context.Context) Report {
+	if !c.ready.Load() {
+		return Report{
+			Status: StatusFail,
+			Checks: []CheckResult{{Name: "ready", Status: StatusFail, Error: ErrNotReady.Error()}},
+		}
+	}
+
+	c.mu.RLock()
+	checks := c.readiness
+	c.mu.RUnlock()
+
+	return c.run(ctx, checks)
+}
+
+func (c *Checker) newCheck(name string, fn CheckFunc, timeout time.Duration) *check {
+	if timeout <= 0 {
+		timeout = c.defaultTimeout
+	}
+	return &check{name: name, fn: fn, timeout: timeout}
+}
+
+func (c *Checker) run(ctx context.Context, checks []*check) Report {
+	report := Report{
+		Status: StatusOK,
+		Checks: make([]CheckResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = chk.run(ctx, c.cacheTTL)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+func (chk *check) run(ctx context.Context, cacheTTL time.Duration) CheckResult {
+	chk.mu.Lock()
+	defer chk.mu.Unlock()
+
+	if !chk.checkedAt.IsZero() && time.Since(chk.checkedAt) < cacheTTL {
+		result := chk.last
+		result.Cached = true
+		return result
+	}
+
+	probeCtx := ctx
+	ctx, cancel := context.WithTimeout(ctx, chk.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	// Run the check on its own goroutine so that one ignoring its context
+	// cannot hold the probe past the timeout.
+	errCh := make(chan error, 1)
+	go func() { errCh <- chk.fn(ctx) }()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Name:     chk.name,
+		Status:   StatusOK,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+		slog.WarnContext(ctx, "health check failed", "check", chk.name, "error", err)
+	}
+
+	// A probe that gave up says nothing about the dependency, so its result
+	// must not be served to the probes that follow.
+	if probeCtx.Err() == nil {
+		chk.last = result
+		chk.checkedAt = time.Now()
+	}
+	return result
+}
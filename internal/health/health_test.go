@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestChecker_ReadyRequiresSetReady(t *testing.T) {
+	checker := NewChecker(0, time.Second)
+	checker.AddReadinessCheck("ok", func(ctx context.Context) error { return nil }, 0)
+
+	if checker.Ready(context.Background()).Healthy() {
+		t.Error("expected readiness to fail before SetReady(true)")
+	}
+
+	checker.SetReady(true)
+	if !checker.Ready(context.Background()).Healthy() {
+		t.Error("expected readiness to pass after SetReady(true)")
+	}
+
+	checker.SetReady(false)
+	if checker.Ready(context.Background()).Healthy() {
+		t.Error("expected readiness to fail while draining")
+	}
+}
+
+func TestChecker_FailingCheck(t *testing.T) {
+	checker := NewChecker(0, time.Second)
+	checker.SetReady(true)
+	checker.AddReadinessCheck("ok", func(ctx context.Context) error { return nil }, 0)
+	checker.AddReadinessCheck("database", func(ctx context.Context) error { return errors.New("connection refused") }, 0)
+
+	report := checker.Ready(context.Background())
+	if report.Healthy() {
+		t.Fatal("expected report to be unhealthy")
+	}
+	if report.Checks[1].Status != StatusFail || report.Checks[1].Error != "connection refused" {
+		t.Errorf("unexpected result for failing check: %+v", report.Checks[1])
+	}
+	if report.Checks[0].Status != StatusOK {
+		t.Errorf("unexpected result for passing check: %+v", report.Checks[0])
+	}
+}
+
+func TestChecker_Timeout(t *testing.T) {
+	checker := NewChecker(0, time.Second)
+	checker.AddLivenessCheck("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, 10*time.Millisecond)
+
+	start := time.Now()
+	report := checker.Live(context.Background())
+	if report.Healthy() {
+		t.Error("expected slow check to fail")
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("expected check to be cut off at its timeout")
+	}
+}
+
+func TestChecker_CachesResults(t *testing.T) {
+	var calls int32
+	checker := NewChecker(time.Minute, time.Second)
+	checker.AddLivenessCheck("counted", func(ctx context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, 0)
+
+	checker.Live(context.Background())
+	report := checker.Live(context.Background())
+
+	if calls != 1 {
+		t.Errorf("expected check to run once, ran %d times", calls)
+	}
+	if !report.Checks[0].Cached {
+		t.Error("expected second result to be served from cache")
+	}
+}
+
+func TestReadyzHandler_Verbose(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	checker := NewChecker(0, time.Second)
+	checker.AddReadinessCheck("database", func(ctx context.Context) error { return nil }, 0)
+	checker.AddReadinessCheck("migrations", func(ctx context.Context) error {
+		return errors.New(`pq: password authentication failed for user "app"`)
+	}, 0)
+
+	router := gin.New()
+	router.GET("/readyz", checker.ReadyzHandler)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Body.String() != StatusFail {
+		t.Errorf("expected 503 fail before ready, got %d %q", w.Code, w.Body.String())
+	}
+
+	checker.SetReady(true)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz?verbose", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+	want := `{"status":"fail","checks":[{"name":"database","status":"ok"},{"name":"migrations","status":"fail"}]}`
+	if w.Body.String() != want {
+		t.Errorf("expected only check names and statuses, got %s", w.Body.String())
+	}
+}
+
+func TestChecker_AbandonedProbeNotCached(t *testing.T) {
+	checker := NewChecker(time.Minute, time.Second)
+	checker.AddLivenessCheck("context", func(ctx context.Context) error { return ctx.Err() }, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if checker.Live(ctx).Healthy() {
+		t.Fatal("expected the cancelled probe to fail")
+	}
+
+	report := checker.Live(context.Background())
+	if !report.Healthy() || report.Checks[0].Cached {
+		t.Errorf("expected the next probe to run the check again, got %+v", report)
+	}
+}
+
+func TestDetailsHandler(t *testing.T) {
+	checker := NewChecker(0, time.Second)
+	checker.AddReadinessCheck("migrations", func(ctx context.Context) error {
+		return errors.New("database version 1 is behind latest migration 9")
+	}, 0)
+	checker.SetReady(true)
+
+	w := httptest.NewRecorder()
+	checker.DetailsHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", w.Code)
+	}
+
+	var details struct {
+		Ready Report `json:"ready"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &details); err != nil {
+		t.Fatalf("failed to decode details: %v", err)
+	}
+	result := details.Ready.Checks[0]
+	if result.Error != "database version 1 is behind latest migration 9" || result.Duration == "" {
+		t.Errorf("expected the error and duration of the check, got %+v", result)
+	}
+}
@@ -0,0 +1,196 @@
+package migration
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+const (
+	timestampFormat  = "20060102150405"
+	sequentialFormat = "%06d"
+)
+
+var sqlTemplate = template.Must(template.New("sql").Parse(`-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+-- +goose StatementEnd
+`))
+
+var goTemplate = template.Must(template.New("go").Parse(`package schema
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pressly/goose/v3"
+)
+
+func init() {
+	goose.AddMigrationContext(up{{.CamelName}}{{.Version}}, down{{.CamelName}}{{.Version}})
+}
+
+func up{{.CamelName}}{{.Version}}(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is applied.
+	return nil
+}
+
+func down{{.CamelName}}{{.Version}}(ctx context.Context, tx *sql.Tx) error {
+	// This code is executed when the migration is rolled back.
+	return nil
+}
+`))
+
+type migrationFile struct {
+	path string
+	tmpl *template.Template
+}
+
+// Create writes a new timestamped migration named name below dir, the
+// schema directory in the source tree, and returns the files created. A
+// "sql" migration gets one file per dialect sharing the same version;
+// a "go" migration is a single file in the schema package, whose functions
+// carry the version so that two migrations may share a name.
+//
+// Timestamps keep migrations written on separate branches from colliding;
+// renumber them with Fix before merging.
+func Create(dir, name, kind string, now time.Time) ([]string, error) {
+	snakeName := snakeCase(name)
+	if snakeName == "" {
+		return nil, fmt.Errorf("invalid migration name %q", name)
+	}
+	version := now.UTC().Format(timestampFormat)
+	data := struct{ CamelName, Version string }{camelCase(snakeName), version}
+
+	var targets []migrationFile
+	switch kind {
+	case "sql":
+		for _, dialect := range Dialects {
+			targets = append(targets, migrationFile{filepath.Join(dir, dialect, version+"_"+snakeName+".sql"), sqlTemplate})
+		}
+	case "go":
+		targets = append(targets, migrationFile{filepath.Join(dir, version+"_"+snakeName+".go"), goTemplate})
+	default:
+		return nil, fmt.Errorf("unknown migration type %q, expected sql or go", kind)
+	}
+
+	var created []string
+	for _, target := range targets {
+		if err := writeTemplate(target.path, target.tmpl, data); err != nil {
+			return created, err
+		}
+		created = append(created, target.path)
+	}
+
+	return created, nil
+}
+
+// Fix renumbers the timestamped migrations below dir, in timestamp order, to
+// follow the latest sequential one, like goose fix, and returns the renamed
+// files. goose refuses to apply a migration older than the latest applied
+// one, so a timestamped migration merged after a newer one has been deployed
+// would stop every later migration.
+func Fix(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	for _, dialect := range Dialects {
+		sqlFiles, err := filepath.Glob(filepath.Join(dir, dialect, "*.sql"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list migrations: %w", err)
+		}
+		files = append(files, sqlFiles...)
+	}
+
+	var next int64 = 1
+	timestamped := map[string][]string{}
+	for _, file := range files {
+		prefix, _, found := strings.Cut(filepath.Base(file), "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !found || err != nil {
+			continue
+		}
+		if len(prefix) == len(timestampFormat) {
+			timestamped[prefix] = append(timestamped[prefix], file)
+		} else if version >= next {
+			next = version + 1
+		}
+	}
+
+	var renamed []string
+	versions := make([]string, 0, len(timestamped))
+	for version := range timestamped {
+		versions = append(versions, version)
+	}
+	slices.Sort(versions)
+	for _, version := range versions {
+		for _, file := range timestamped[version] {
+			path := filepath.Join(filepath.Dir(file), fmt.Sprintf(sequentialFormat, next)+strings.TrimPrefix(filepath.Base(file), version))
+			if _, err := os.Lstat(path); err == nil {
+				return renamed, fmt.Errorf("failed to renumber %s: %s already exists", file, path)
+			}
+			if err := os.Rename(file, path); err != nil {
+				return renamed, fmt.Errorf("failed to renumber migration: %w", err)
+			}
+			renamed = append(renamed, path)
+		}
+		next++
+	}
+
+	return renamed, nil
+}
+
+func writeTemplate(path string, tmpl *template.Template, data any) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create migration: %w", err)
+	}
+	defer f.Close()
+
+	if err := tmpl.Execute(f, data); err != nil {
+		return fmt.Errorf("failed to write migration: %w", err)
+	}
+	return nil
+}
+
+// snakeCase turns "AddUserIndex" or "add user index" into "add_user_index".
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range strings.TrimSpace(name) {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && !strings.HasSuffix(b.String(), "_") {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "_"):
+			b.WriteByte('_')
+		}
+	}
+	return strings.Trim(b.String(), "_")
+}
+
+func camelCase(snake string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(snake, "_") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,210 @@
+package migration
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// compatibleTypes maps GORM data types to the column types, lower cased, the
+// migrations may use for them on any supported database.
+var compatibleTypes = map[schema.DataType][]string{
+	schema.Bool:   {"bool", "boolean", "tinyint"},
+	schema.Int:    {"smallint", "int", "integer", "bigint", "int2", "int4", "int8", "tinyint", "mediumint"},
+	schema.Uint:   {"smallint", "int", "integer", "bigint", "int2", "int4", "int8", "tinyint", "mediumint"},
+	schema.Float:  {"real", "float", "double", "numeric", "decimal", "float4", "float8"},
+	schema.String: {"text", "varchar", "char", "uuid", "bpchar", "longtext", "mediumtext", "tinytext"},
+	schema.Time:   {"timestamp", "timestamptz", "datetime", "date"},
+	schema.Bytes:  {"bytea", "blob", "longblob", "mediumblob", "binary", "varbinary"},
+}
+
+// CheckDrift compares the tables of models, as GORM understands them, with
+// the live schema of db and returns one problem per difference: missing or
+// extra tables, columns and indexes, incompatible types, sizes and
+// nullability. The schema matches the models when no problems are returned.
+//
+// Models with an UnmanagedIndexes() []string method name indexes that
+// migrations create with features GORM cannot declare, such as full-text
+// indexes; those are not compared.
+func CheckDrift(db *gorm.DB, models ...any) ([]string, error) {
+	var problems []string
+	cache := &sync.Map{}
+
+	for _, model := range models {
+		sch, err := schema.Parse(model, cache, db.NamingStrategy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %w", model, err)
+		}
+
+		modelProblems, err := checkTable(db, model, sch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to inspect table %s: %w", sch.Table, err)
+		}
+		problems = append(problems, modelProblems...)
+	}
+
+	return problems, nil
+}
+
+func checkTable(db *gorm.DB, model any, sch *schema.Schema) ([]string, error) {
+	migrator := db.Migrator()
+	if !migrator.HasTable(model) {
+		return []string{sch.Table + ": table is missing"}, nil
+	}
+
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, sch.Table+"."+fmt.Sprintf(format, args...))
+	}
+
+	columnTypes, err := migrator.ColumnTypes(model)
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]gorm.ColumnType, len(columnTypes))
+	for _, column := range columnTypes {
+		columns[column.Name()] = column
+	}
+
+	for _, field := range sch.Fields {
+		if field.DBName == "" {
+			continue
+		}
+
+		column, ok := columns[field.DBName]
+		if !ok {
+			addf("%s: column is missing", field.DBName)
+			continue
+		}
+		delete(columns, field.DBName)
+
+		for _, problem := range checkColumn(field, column) {
+			addf("%s: %s", field.DBName, problem)
+		}
+	}
+	for name := range columns {
+		addf("%s: column is not in the model", name)
+	}
+
+	indexProblems, err := checkIndexes(migrator, model, sch)
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range indexProblems {
+		addf("%s", problem)
+	}
+
+	slices.Sort(problems)
+	return problems, nil
+}
+
+func checkColumn(field *schema.Field, column gorm.ColumnType) []string {
+	var problems []string
+
+	dbType := strings.ToLower(column.DatabaseTypeName())
+	if compatible, known := compatibleTypes[field.DataType]; known && !slices.Contains(compatible, dbType) {
+		problems = append(problems, fmt.Sprintf("type %s does not match model type %s", dbType, field.DataType))
+	}
+
+	// Only bounded character types have a size to compare; TEXT reports a
+	// driver specific maximum on some databases.
+	if dbType == "varchar" || dbType == "char" || dbType == "bpchar" {
+		if length, ok := column.Length(); ok && length > 0 && int64(field.Size) != length {
+			problems = append(problems, fmt.Sprintf("size %d does not match model size %d", length, field.Size))
+		}
+	}
+
+	// Some databases allow NULL in non-integer primary keys, so nullability
+	// is only compared for regular columns.
+	if nullable, ok := column.Nullable(); ok && !field.PrimaryKey && nullable == field.NotNull {
+		if field.NotNull {
+			problems = append(problems, "column is nullable but the model is not null")
+		} else {
+			problems = append(problems, "column is not null but the model is nullable")
+		}
+	}
+
+	return problems
+}
+
+type indexDef struct {
+	columns []string
+	unique  bool
+}
+
+func checkIndexes(migrator gorm.Migrator, model any, sch *schema.Schema) ([]string, error) {
+	dbIndexes, err := migrator.GetIndexes(model)
+	if err != nil {
+		return nil, err
+	}
+
+	actual := make(map[string]indexDef)
+	for _, index := range dbIndexes {
+		if primary, _ := index.PrimaryKey(); primary {
+			continue
+		}
+		unique, _ := index.Unique()
+		actual[index.Name()] = indexDef{columns: index.Columns(), unique: unique}
+	}
+
+	if unmanaged, ok := model.(interface{ UnmanagedIndexes() []string }); ok {
+		for _, name := range unmanaged.UnmanagedIndexes() {
+			delete(actual, name)
+		}
+	}
+
+	expected := make(map[string]indexDef)
+	for _, index := range sch.ParseIndexes() {
+		def := indexDef{unique: index.Class == "UNIQUE"}
+		for _, option := range index.Fields {
+			def.columns = append(def.columns, option.DBName)
+		}
+		expected[index.Name] = def
+	}
+
+	var problems []string
+	for name, want := range expected {
+		got, ok := actual[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: index is missing", name))
+			continue
+		}
+		delete(actual, name)
+
+		if !slices.Equal(got.columns, want.columns) {
+			problems = append(problems, fmt.Sprintf("%s: index covers %v but the model expects %v", name, got.columns, want.columns))
+		}
+		if got.unique != want.unique {
+			problems = append(problems, fmt.Sprintf("%s: index unique is %t but the model expects %t", name, got.unique, want.unique))
+		}
+	}
+
+	// Unique constraints declared with the unique tag are named by the
+	// database, so match them by column instead.
+	for _, field := range sch.Fields {
+		if !field.Unique {
+			continue
+		}
+		found := false
+		for name, got := range actual {
+			if got.unique && slices.Equal(got.columns, []string{field.DBName}) {
+				delete(actual, name)
+				found = true
+				break
+			}
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: unique constraint is missing", field.DBName))
+		}
+	}
+
+	for name, got := range actual {
+		problems = append(problems, fmt.Sprintf("%s: index on %v is not in the model", name, got.columns))
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,58 @@
+package migration
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// driftingUser disagrees with the users table in several ways.
+type driftingUser struct {
+	ID        string `gorm:"primaryKey"`
+	Email     string `gorm:"size:64;uniqueIndex;not null"`
+	Name      int
+	Nickname  string
+	CreatedAt time.Time `gorm:"not null"`
+	IsActive  bool      `gorm:"not null;index"`
+}
+
+func (driftingUser) TableName() string { return "users" }
+
+func TestCheckDrift(t *testing.T) {
+	db := openSQLiteFile(t, ":memory:")
+	if err := MigrateUp(db, "sqlite", 0); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	problems, err := CheckDrift(db, &driftingUser{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	report := strings.Join(problems, "\n")
+
+	for _, want := range []string{
+		"users.email: size 128 does not match model size 64",
+		"users.name: type text does not match model type int",
+		"users.name: column is not null but the model is nullable",
+		"users.nickname: column is missing",
+		"users.password: column is not in the model",
+		"users.idx_users_is_active: index is missing",
+		"users.idx_users_deleted_at: index on [deleted_at] is not in the model",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected problem %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestCheckDrift_MissingTable(t *testing.T) {
+	db := openSQLiteFile(t, ":memory:")
+
+	problems, err := CheckDrift(db, &driftingUser{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(problems) != 1 || problems[0] != "users: table is missing" {
+		t.Errorf("expected missing table, got %v", problems)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package migration
+
+import "os"
+
+// lockFile does not lock on platforms without flock; SQLite deployments
+// there are expected to run a single process.
+func lockFile(file *os.File) (bool, error) {
+	return true, nil
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
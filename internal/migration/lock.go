@@ -0,0 +1,301 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// lockName identifies the migration lock on every database type.
+const lockName = "go-api-starter.migrations"
+
+// DefaultLockTimeout is how long migrations wait for a lock held by another
+// process when given no timeout.
+const DefaultLockTimeout = 5 * time.Minute
+
+var lockPollInterval = time.Second
+
+// migrationLock is a lock shared by every process migrating the same
+// database.
+type migrationLock interface {
+	// tryLock takes the lock if it is free and reports whether it did.
+	tryLock(ctx context.Context) (bool, error)
+	unlock(ctx context.Context) error
+	// holder describes who holds the lock, for logging.
+	holder(ctx context.Context) string
+	close() error
+}
+
+// withLock runs fn while holding the migration lock. When another process
+// holds it, withLock waits up to lockTimeout until the lock is free or, if
+// upToDate is given, until upToDate reports the other process has finished
+// the work, in which case fn is skipped.
+func withLock(ctx context.Context, sqlDB *sql.DB, dbType string, lockTimeout time.Duration, upToDate func() bool, fn func() error) error {
+	if lockTimeout <= 0 {
+		lockTimeout = DefaultLockTimeout
+	}
+
+	lock, err := newMigrationLock(ctx, sqlDB, dbType)
+	if err != nil {
+		return fmt.Errorf("failed to prepare migration lock: %w", err)
+	}
+	defer lock.close()
+
+	ctx, cancel := context.WithTimeout(ctx, lockTimeout)
+	defer cancel()
+
+	for waiting := false; ; waiting = true {
+		acquired, err := lock.tryLock(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+
+		if upToDate != nil && upToDate() {
+			slog.Info("schema migrated by another process, continuing")
+			return nil
+		}
+		if !waiting {
+			slog.Info("waiting for migration lock", "holder", lock.holder(ctx), "timeout", lockTimeout)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out after %s waiting for migration lock held by %s", lockTimeout, lock.holder(context.Background()))
+		case <-time.After(lockPollInterval):
+		}
+	}
+
+	defer func() {
+		if err := lock.unlock(context.Background()); err != nil {
+			slog.Error("failed to release migration lock", "error", err)
+		}
+	}()
+
+	return fn()
+}
+
+func newMigrationLock(ctx context.Context, sqlDB *sql.DB, dbType string) (migrationLock, error) {
+	dialect, err := dialectOf(dbType)
+	if err != nil {
+		return nil, err
+	}
+
+	switch dialect {
+	case "postgres":
+		return newPostgresLock(ctx, sqlDB)
+	case "mysql":
+		return newMySQLLock(ctx, sqlDB)
+	case "sqlite":
+		return newSQLiteLock(ctx, sqlDB)
+	default:
+		return nil, fmt.Errorf("no migration lock for dialect %q", dialect)
+	}
+}
+
+// lockOwner identifies this process to others waiting for the lock.
+func lockOwner() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s pid %d", hostname, os.Getpid())
+}
+
+// postgresLock is a session level advisory lock. It lives on a dedicated
+// connection, so the pool must allow at least one more for the migrations.
+// Naming the session outlives the lock, so the connection is discarded on
+// close.
+type postgresLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+func newPostgresLock(ctx context.Context, sqlDB *sql.DB) (*postgresLock, error) {
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Name the session so waiting processes can tell who holds the lock.
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('application_name', $1, false)", "migrate "+lockOwner()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &postgresLock{conn: conn, key: int64(crc32.ChecksumIEEE([]byte(lockName)))}, nil
+}
+
+func (l *postgresLock) tryLock(ctx context.Context) (bool, error) {
+	var acquired bool
+	err := l.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", l.key).Scan(&acquired)
+	return acquired, err
+}
+
+func (l *postgresLock) unlock(ctx context.Context) error {
+	_, err := l.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", l.key)
+	return err
+}
+
+func (l *postgresLock) holder(ctx context.Context) string {
+	var (
+		pid         int
+		application string
+		client      string
+		since       time.Time
+	)
+	// A bigint key below 2^32 is stored with classid 0 and objsubid 1.
+	err := l.conn.QueryRowContext(ctx, `
+		SELECT a.pid, COALESCE(a.application_name, ''), COALESCE(host(a.client_addr), 'local'), a.backend_start
+		FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid
+		WHERE l.locktype = 'advisory' AND l.granted AND l.classid = 0 AND l.objid = $1 AND l.objsubid = 1`,
+		l.key,
+	).Scan(&pid, &application, &client, &since)
+	if err != nil {
+		return "unknown"
+	}
+	return fmt.Sprintf("%q (backend pid %d from %s since %s)", application, pid, client, since.Format(time.RFC3339))
+}
+
+func (l *postgresLock) close() error {
+	return discardConn(l.conn)
+}
+
+// mysqlLock is a named lock taken with GET_LOCK on a dedicated connection.
+type mysqlLock struct {
+	conn *sql.Conn
+}
+
+func newMySQLLock(ctx context.Context, sqlDB *sql.DB) (*mysqlLock, error) {
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &mysqlLock{conn: conn}, nil
+}
+
+func (l *mysqlLock) tryLock(ctx context.Context) (bool, error) {
+	var acquired sql.NullInt64
+	if err := l.conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 0)", lockName).Scan(&acquired); err != nil {
+		return false, err
+	}
+	if !acquired.Valid {
+		return false, errors.New("GET_LOCK failed")
+	}
+	return acquired.Int64 == 1, nil
+}
+
+func (l *mysqlLock) unlock(ctx context.Context) error {
+	_, err := l.conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+	return err
+}
+
+func (l *mysqlLock) holder(ctx context.Context) string {
+	var id sql.NullInt64
+	if err := l.conn.QueryRowContext(ctx, "SELECT IS_USED_LOCK(?)", lockName).Scan(&id); err != nil || !id.Valid {
+		return "unknown"
+	}
+
+	var user, host string
+	err := l.conn.QueryRowContext(ctx,
+		"SELECT COALESCE(USER, ''), COALESCE(HOST, '') FROM information_schema.PROCESSLIST WHERE ID = ?", id.Int64,
+	).Scan(&user, &host)
+	if err != nil {
+		return fmt.Sprintf("connection %d", id.Int64)
+	}
+	return fmt.Sprintf("connection %d (%s@%s)", id.Int64, user, host)
+}
+
+// close discards the connection, which ends the session and with it a lock
+// RELEASE_LOCK failed to release.
+func (l *mysqlLock) close() error {
+	return discardConn(l.conn)
+}
+
+// discardConn closes the connection behind conn instead of returning it to
+// the pool, so no session state such as a lock or name is left behind.
+func discardConn(conn *sql.Conn) error {
+	err := conn.Raw(func(any) error { return driver.ErrBadConn })
+	if errors.Is(err, driver.ErrBadConn) {
+		return nil
+	}
+	return err
+}
+
+// sqliteLock is an exclusive lock on a file next to the database. An
+// in-memory database cannot be shared between processes and needs no lock.
+type sqliteLock struct {
+	path string
+	file *os.File
+}
+
+func newSQLiteLock(ctx context.Context, sqlDB *sql.DB) (migrationLock, error) {
+	var (
+		seq        int
+		name, path string
+	)
+	if err := sqlDB.QueryRowContext(ctx, "PRAGMA database_list").Scan(&seq, &name, &path); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return noLock{}, nil
+	}
+	return &sqliteLock{path: path + ".migrate.lock"}, nil
+}
+
+func (l *sqliteLock) tryLock(ctx context.Context) (bool, error) {
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return false, err
+	}
+
+	acquired, err := lockFile(file)
+	if err != nil || !acquired {
+		file.Close()
+		return false, err
+	}
+
+	// Record the owner for processes waiting on the lock.
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(lockOwner()), 0)
+	}
+	l.file = file
+	return true, nil
+}
+
+func (l *sqliteLock) unlock(ctx context.Context) error {
+	if l.file == nil {
+		return nil
+	}
+	err := unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+	return err
+}
+
+func (l *sqliteLock) holder(ctx context.Context) string {
+	owner, err := os.ReadFile(l.path)
+	if err != nil || len(owner) == 0 {
+		return "unknown"
+	}
+	return string(owner)
+}
+
+func (l *sqliteLock) close() error {
+	return l.unlock(context.Background())
+}
+
+type noLock struct{}
+
+func (noLock) tryLock(ctx context.Context) (bool, error) { return true, nil }
+func (noLock) unlock(ctx context.Context) error          { return nil }
+func (noLock) holder(ctx context.Context) string         { return "" }
+func (noLock) close() error                              { return nil }
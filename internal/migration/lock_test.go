@@ -0,0 +1,101 @@
+package migration
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+	"gorm.io/gorm"
+)
+
+func openSQLiteFile(t *testing.T, path string) *gorm.DB {
+	t.Helper()
+
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = path
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// holdLock takes the migration lock for db as another
+// process would, until the test ends.
+func holdLock(t *testing.T, db *gorm.DB) {
+	t.Helper()
+
+	sqlDB, _ := db.DB()
+	lock, err := newMigrationLock(context.Background(), sqlDB, "sqlite")
+	if err != nil {
+		t.Fatalf("failed to prepare lock: %v", err)
+	}
+	if acquired, err := lock.tryLock(context.Background()); err != nil || !acquired {
+		t.Fatalf("expected to acquire free lock, got %v, %v", acquired, err)
+	}
+	t.Cleanup(func() { lock.close() })
+}
+
+const shortLockTimeout = 200 * time.Millisecond
+
+func fastLockPolling(t *testing.T) {
+	previous := lockPollInterval
+	lockPollInterval = 10 * time.Millisecond
+	t.Cleanup(func() { lockPollInterval = previous })
+}
+
+func TestMigrateUp_WaitsForLock(t *testing.T) {
+	fastLockPolling(t)
+	db := openSQLiteFile(t, filepath.Join(t.TempDir(), "app.db"))
+	holdLock(t, db)
+
+	err := MigrateUp(db, "sqlite", shortLockTimeout)
+	if err == nil {
+		t.Fatal("expected timeout while another process holds the lock")
+	}
+	if !strings.Contains(err.Error(), "held by") || !strings.Contains(err.Error(), "pid") {
+		t.Errorf("expected error to name the lock holder, got %v", err)
+	}
+}
+
+func TestMigrateUp_ContinuesWhenMigratedByHolder(t *testing.T) {
+	fastLockPolling(t)
+	path := filepath.Join(t.TempDir(), "app.db")
+
+	// Another process migrated the schema and still holds the lock.
+	if err := MigrateUp(openSQLiteFile(t, path), "sqlite", shortLockTimeout); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	db := openSQLiteFile(t, path)
+	holdLock(t, db)
+
+	if err := MigrateUp(db, "sqlite", shortLockTimeout); err != nil {
+		t.Errorf("expected up to date schema not to wait for the lock, got %v", err)
+	}
+	if err := MigrateDown(db, "sqlite", shortLockTimeout); err == nil {
+		t.Error("expected down to wait for the lock and time out")
+	}
+}
+
+func TestMigrateUp_ReleasesLock(t *testing.T) {
+	fastLockPolling(t)
+	db := openSQLiteFile(t, filepath.Join(t.TempDir(), "app.db"))
+
+	if err := MigrateUp(db, "sqlite", shortLockTimeout); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	if err := MigrateDown(db, "sqlite", shortLockTimeout); err != nil {
+		t.Errorf("expected lock to be free after migrating, got %v", err)
+	}
+}
@@ -1,31 +1,245 @@
 package migration
 
 import (
+	"context"
+	"database/sql"
 	"embed"
 	"fmt"
+	"io"
+	"log"
+	"math"
+	"slices"
+	"time"
 
 	"github.com/pressly/goose/v3"
+	"github.com/shuv1824/go-api-starter/pkg/database"
 	"gorm.io/gorm"
+
+	// Go migrations register themselves with goose and apply to every
+	// database type.
+	"github.com/shuv1824/go-api-starter/internal/migration/schema"
 )
 
-//go:embed schema/*.sql
+// Each dialect has its own migrations under schema/<dialect>, numbered
+// identically so every backend ends up at the same schema version.
+//
+//go:embed schema/*/*.sql
 var embededSchema embed.FS
 
-func MigrateUp(db *gorm.DB, dbType string) error {
+// Dialects lists the dialects with a migrations directory. Database types
+// are mapped to one of them by their registered database.Driver.
+var Dialects = []string{"postgres", "mysql", "sqlite"}
+
+// MigrateUp applies all pending migrations. When several processes start at
+// once, one of them migrates while the others wait up to lockTimeout for the
+// lock, or for the schema to reach the latest version, and then continue.
+// Every function changing the schema takes a lock timeout, where zero means
+// DefaultLockTimeout.
+func MigrateUp(db *gorm.DB, dbType string, lockTimeout time.Duration) error {
+	return run(db, dbType, func(ctx context.Context, sqlDB *sql.DB, dir string) error {
+		upToDate := func() bool { return checkUpToDate(ctx, db, dir) == nil }
+		return withLock(ctx, sqlDB, dbType, lockTimeout, upToDate, func() error {
+			if err := goose.UpContext(ctx, sqlDB, dir); err != nil {
+				return fmt.Errorf("failed to migrate database: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// MigrateUpTo applies pending migrations up to and including version.
+func MigrateUpTo(db *gorm.DB, dbType string, version int64, lockTimeout time.Duration) error {
+	return run(db, dbType, func(ctx context.Context, sqlDB *sql.DB, dir string) error {
+		return withLock(ctx, sqlDB, dbType, lockTimeout, nil, func() error {
+			if err := goose.UpToContext(ctx, sqlDB, dir, version); err != nil {
+				return fmt.Errorf("failed to migrate database to version %d: %w", version, err)
+			}
+			return nil
+		})
+	})
+}
+
+// MigrateDown rolls back the most recently applied migration.
+func MigrateDown(db *gorm.DB, dbType string, lockTimeout time.Duration) error {
+	return run(db, dbType, func(ctx context.Context, sqlDB *sql.DB, dir string) error {
+		return withLock(ctx, sqlDB, dbType, lockTimeout, nil, func() error {
+			if err := goose.DownContext(ctx, sqlDB, dir); err != nil {
+				return fmt.Errorf("failed to roll back migration: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// MigrateDownTo rolls back migrations until version is the latest applied
+// one. Version 0 rolls back every migration.
+func MigrateDownTo(db *gorm.DB, dbType string, version int64, lockTimeout time.Duration) error {
+	return run(db, dbType, func(ctx context.Context, sqlDB *sql.DB, dir string) error {
+		return withLock(ctx, sqlDB, dbType, lockTimeout, nil, func() error {
+			if err := goose.DownToContext(ctx, sqlDB, dir, version); err != nil {
+				return fmt.Errorf("failed to roll back to version %d: %w", version, err)
+			}
+			return nil
+		})
+	})
+}
+
+// Redo rolls back the most recently applied migration and applies it again.
+func Redo(db *gorm.DB, dbType string, lockTimeout time.Duration) error {
+	return run(db, dbType, func(ctx context.Context, sqlDB *sql.DB, dir string) error {
+		return withLock(ctx, sqlDB, dbType, lockTimeout, nil, func() error {
+			if err := goose.RedoContext(ctx, sqlDB, dir); err != nil {
+				return fmt.Errorf("failed to redo migration: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// Status writes every migration and when it was applied to w.
+func Status(db *gorm.DB, dbType string, w io.Writer) error {
+	return run(db, dbType, func(ctx context.Context, sqlDB *sql.DB, dir string) error {
+		goose.SetLogger(log.New(w, "", 0))
+		defer goose.SetLogger(log.Default())
+
+		if err := goose.StatusContext(ctx, sqlDB, dir); err != nil {
+			return fmt.Errorf("failed to get migration status: %w", err)
+		}
+		return nil
+	})
+}
+
+// Version returns the latest migration applied to the database.
+func Version(db *gorm.DB, dbType string) (int64, error) {
+	var version int64
+	err := run(db, dbType, func(ctx context.Context, _ *sql.DB, _ string) error {
+		var err error
+		version, err = currentVersion(ctx, db)
+		return err
+	})
+	return version, err
+}
+
+// CheckUpToDate returns an error when the database has not been migrated to
+// the latest embedded migration.
+func CheckUpToDate(ctx context.Context, db *gorm.DB, dbType string) error {
+	return run(db, dbType, func(_ context.Context, _ *sql.DB, dir string) error {
+		return checkUpToDate(ctx, db, dir)
+	})
+}
+
+// UpToDateCheck returns a check failing while the database is behind the
+// latest migration. The migrations are collected once here; the check only
+// reads the goose version table, so it is safe to run alongside migrations.
+func UpToDateCheck(db *gorm.DB, dbType string) (func(ctx context.Context) error, error) {
+	var latest int64
+	err := run(db, dbType, func(_ context.Context, _ *sql.DB, dir string) error {
+		var err error
+		latest, err = latestVersion(dir)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		return behind(ctx, db, latest)
+	}, nil
+}
+
+func checkUpToDate(ctx context.Context, db *gorm.DB, dir string) error {
+	latest, err := latestVersion(dir)
+	if err != nil {
+		return err
+	}
+	return behind(ctx, db, latest)
+}
+
+// behind returns an error when the database is at a version below latest.
+func behind(ctx context.Context, db *gorm.DB, latest int64) error {
+	current, err := currentVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current < latest {
+		return fmt.Errorf("database version %d is behind latest migration %d", current, latest)
+	}
+	return nil
+}
+
+// latestVersion returns the version of the last migration in dir.
+func latestVersion(dir string) (int64, error) {
+	migrations, err := goose.CollectMigrations(dir, 0, math.MaxInt64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to collect migrations: %w", err)
+	}
+	last, err := migrations.Last()
+	if err != nil {
+		return 0, fmt.Errorf("failed to find latest migration: %w", err)
+	}
+	return last.Version, nil
+}
+
+// currentVersion reads the latest applied migration without goose, which
+// creates its version table when it is missing; a database without the
+// table is at version 0. Rolling back deletes the version row, so the
+// highest one is current.
+func currentVersion(ctx context.Context, db *gorm.DB) (int64, error) {
+	db = db.WithContext(ctx)
+	if !db.Migrator().HasTable(goose.TableName()) {
+		return 0, nil
+	}
+
+	var current int64
+	query := fmt.Sprintf("SELECT COALESCE(MAX(version_id), 0) FROM %s", goose.TableName())
+	if err := db.Raw(query).Scan(&current).Error; err != nil {
+		return 0, fmt.Errorf("failed to get database version: %w", err)
+	}
+	return current, nil
+}
+
+// run calls fn with the connection pool of db and the migrations directory
+// for the dialect of dbType. The context tells Go migrations the dialect.
+func run(db *gorm.DB, dbType string, fn func(ctx context.Context, sqlDB *sql.DB, dir string) error) error {
 	sqlDB, err := db.DB()
 	if err != nil {
 		return fmt.Errorf("failed to get database instance: %w", err)
 	}
 
-	goose.SetBaseFS(embededSchema)
+	dialect, err := setup(dbType)
+	if err != nil {
+		return err
+	}
 
-	if err := goose.SetDialect(dbType); err != nil {
-		return fmt.Errorf("failed to set database dialect: %w", err)
+	return fn(schema.WithDialect(context.Background(), dialect), sqlDB, "schema/"+dialect)
+}
+
+// setup points goose at the migrations for the dialect of dbType and returns
+// the dialect.
+func setup(dbType string) (string, error) {
+	dialect, err := dialectOf(dbType)
+	if err != nil {
+		return "", err
 	}
 
-	if err := goose.Up(sqlDB, "schema"); err != nil {
-		return fmt.Errorf("failed to migrate database: %w", err)
+	goose.SetBaseFS(embededSchema)
+
+	if err := goose.SetDialect(dialect); err != nil {
+		return "", fmt.Errorf("failed to set database dialect: %w", err)
 	}
 
-	return nil
+	return dialect, nil
+}
+
+// dialectOf returns the migration dialect of the driver registered for
+// dbType.
+func dialectOf(dbType string) (string, error) {
+	driver, err := database.Lookup(dbType)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(Dialects, driver.Dialect) {
+		return "", fmt.Errorf("no migrations for dialect %q of database type %q", driver.Dialect, dbType)
+	}
+	return driver.Dialect, nil
 }
@@ -0,0 +1,324 @@
+package migration
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pressly/goose/v3"
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+)
+
+func TestSchema_SameVersionsForEveryDialect(t *testing.T) {
+	var want []string
+	for _, dialect := range Dialects {
+		files, err := fs.Glob(embededSchema, "schema/"+dialect+"/*.sql")
+		if err != nil {
+			t.Fatalf("failed to list %s migrations: %v", dialect, err)
+		}
+
+		var names []string
+		for _, file := range files {
+			names = append(names, file[len("schema/"+dialect+"/"):])
+		}
+
+		if want == nil {
+			want = names
+			continue
+		}
+		if !slices.Equal(names, want) {
+			t.Errorf("%s migrations %v differ from postgres %v", dialect, names, want)
+		}
+	}
+}
+
+func TestMigrateUp_SQLite(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = ":memory:"
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	if err := CheckUpToDate(context.Background(), db, cfg.Type); err == nil {
+		t.Error("expected empty database to be behind")
+	}
+	check, err := UpToDateCheck(db, cfg.Type)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := check(context.Background()); err == nil {
+		t.Error("expected the check to fail on an empty database")
+	}
+	if version, err := Version(db, cfg.Type); err != nil || version != 0 {
+		t.Errorf("expected version 0 on an empty database, got %d, %v", version, err)
+	}
+	if db.Migrator().HasTable(goose.TableName()) {
+		t.Error("expected reading the version not to create the version table")
+	}
+
+	if err := MigrateUpTo(db, cfg.Type, 1, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := check(context.Background()); err == nil || !strings.Contains(err.Error(), "database version 1 is behind") {
+		t.Errorf("expected the check to report the database behind, got %v", err)
+	}
+	if version, err := Version(db, cfg.Type); err != nil || version != 1 {
+		t.Errorf("expected version 1, got %d, %v", version, err)
+	}
+
+	if err := MigrateUp(db, cfg.Type, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := CheckUpToDate(context.Background(), db, cfg.Type); err != nil {
+		t.Errorf("expected database to be up to date, got %v", err)
+	}
+	if err := check(context.Background()); err != nil {
+		t.Errorf("expected the check to pass once migrated, got %v", err)
+	}
+}
+
+func TestMigrateUp_RegisteredDriver(t *testing.T) {
+	sqlite, err := database.Lookup("sqlite")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// A third-party driver reusing the SQLite migrations under its own name.
+	sqlite.Name = "Embedded SQL"
+	if _, err := database.Lookup("embedded-sql"); err != nil {
+		database.Register("embedded-sql", sqlite)
+	}
+
+	cfg := config.Default().Database
+	cfg.Type = "embedded-sql"
+	cfg.DbName = ":memory:"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("expected registered type to be valid, got %v", err)
+	}
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close(db)
+
+	if err := MigrateUp(db, cfg.Type, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := CheckUpToDate(context.Background(), db, cfg.Type); err != nil {
+		t.Errorf("expected database to be up to date, got %v", err)
+	}
+}
+
+func TestMigrateUp_NormalizesUserEmails(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = ":memory:"
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close(db)
+
+	if err := MigrateUpTo(db, cfg.Type, 7, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	insert := func(id, email string) {
+		t.Helper()
+		if err := db.Exec("INSERT INTO users (id, name, email, password) VALUES (?, ?, ?, ?)", id, id, email, "hash").Error; err != nil {
+			t.Fatalf("failed to insert user: %v", err)
+		}
+	}
+	insert("1", " Ann@Example.COM")
+	insert("2", "ann@example.com")
+	insert("3", "Bob@Example.com")
+	insert("4", "JOS\u00c9@example.com")
+	insert("5", "jose\u0301@example.com")
+
+	err = MigrateUp(db, cfg.Type, 0)
+	if err == nil {
+		t.Fatal("expected duplicate emails to stop the migration")
+	}
+	for _, want := range []string{"\n  1, 2", "\n  4, 5"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected users %q to be reported, got %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "@") {
+		t.Errorf("expected only IDs to be reported, got %v", err)
+	}
+
+	if err := db.Exec("DELETE FROM users WHERE id IN ?", []string{"2", "5"}).Error; err != nil {
+		t.Fatalf("failed to delete users: %v", err)
+	}
+	if err := MigrateUp(db, cfg.Type, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var users []struct {
+		Email    string
+		EmailKey string
+	}
+	if err := db.Raw("SELECT email, email_key FROM users ORDER BY id").Scan(&users).Error; err != nil {
+		t.Fatalf("failed to read emails: %v", err)
+	}
+	want := []struct {
+		Email    string
+		EmailKey string
+	}{
+		{"Ann@example.com", "ann@example.com"},
+		{"Bob@example.com", "bob@example.com"},
+		{"JOS\u00c9@example.com", "jos\u00e9@example.com"},
+	}
+	if !slices.Equal(users, want) {
+		t.Errorf("expected %v, got %v", want, users)
+	}
+	if err := db.Exec("INSERT INTO users (id, name, email, email_key, password) VALUES ('6', 'Bob', 'BOB@example.com', 'bob@example.com', 'hash')").Error; err == nil {
+		t.Error("expected a second user with the same email key to be rejected")
+	}
+}
+
+func TestMigrateUp_UnknownType(t *testing.T) {
+	if _, err := setup("oracle"); err == nil {
+		t.Error("expected error for unsupported database type")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	for _, dialect := range Dialects {
+		if err := os.Mkdir(filepath.Join(dir, dialect), 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+	}
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	files, err := Create(dir, "AddUserIndex", "sql", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != len(Dialects) {
+		t.Fatalf("expected one file per dialect, got %v", files)
+	}
+	for i, dialect := range Dialects {
+		want := filepath.Join(dir, dialect, "20250102030405_add_user_index.sql")
+		if files[i] != want {
+			t.Errorf("expected %s, got %s", want, files[i])
+		}
+	}
+
+	files, err = Create(dir, "backfill user names", "go", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatalf("failed to read migration: %v", err)
+	}
+	if !strings.Contains(string(content), "func upBackfillUserNames20250102030405(") {
+		t.Errorf("expected Go migration to use the camel case name and version, got:\n%s", content)
+	}
+
+	if _, err := Create(dir, "AddUserIndex", "sql", now); err == nil {
+		t.Error("expected error when the migration already exists")
+	}
+	if _, err := Create(dir, "noop", "xml", now); err == nil {
+		t.Error("expected error for unknown migration type")
+	}
+}
+
+func TestFix(t *testing.T) {
+	dir := t.TempDir()
+	for _, dialect := range Dialects {
+		if err := os.Mkdir(filepath.Join(dir, dialect), 0o755); err != nil {
+			t.Fatalf("failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, dialect, "000009_add_user_role.sql"), nil, 0o644); err != nil {
+			t.Fatalf("failed to write migration: %v", err)
+		}
+	}
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	for i, create := range []struct{ name, kind string }{
+		{"backfill user names", "go"},
+		{"add user index", "sql"},
+	} {
+		// Created out of order, as when merging two branches.
+		if _, err := Create(dir, create.name, create.kind, now.Add(-time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	renamed, err := Fix(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{filepath.Join(dir, "000011_backfill_user_names.go")}
+	for _, dialect := range Dialects {
+		want = append(want, filepath.Join(dir, dialect, "000010_add_user_index.sql"))
+	}
+	slices.Sort(renamed)
+	slices.Sort(want)
+	if !slices.Equal(renamed, want) {
+		t.Errorf("expected %v, got %v", want, renamed)
+	}
+
+	if renamed, err := Fix(dir); err != nil || len(renamed) != 0 {
+		t.Errorf("expected nothing left to renumber, got %v, %v", renamed, err)
+	}
+}
+
+func TestMigrateUp_IndexesExistingUsersForSearch(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = ":memory:"
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer database.Close(db)
+
+	if err := MigrateUpTo(db, cfg.Type, 6, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := db.Exec("INSERT INTO users (id, name, email, password) VALUES ('1', 'Ann Smith', 'ann@example.com', 'hash')").Error; err != nil {
+		t.Fatalf("failed to insert user: %v", err)
+	}
+	if err := MigrateUpTo(db, cfg.Type, 7, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var fts5 bool
+	if err := db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5).Error; err != nil {
+		t.Fatalf("failed to detect FTS5: %v", err)
+	}
+	if got := db.Migrator().HasTable("users_search"); got != fts5 {
+		t.Fatalf("expected the search table only with FTS5 (%v), got %v", fts5, got)
+	}
+	if fts5 {
+		var indexed int64
+		if err := db.Raw("SELECT COUNT(*) FROM users_search WHERE users_search MATCH 'ann*'").Scan(&indexed).Error; err != nil {
+			t.Fatalf("failed to search: %v", err)
+		}
+		if indexed != 1 {
+			t.Errorf("expected the existing user to be indexed, found %d", indexed)
+		}
+	}
+
+	if err := MigrateDownTo(db, cfg.Type, 6, 0); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if db.Migrator().HasTable("users_search") {
+		t.Error("expected rolling back to drop the search table")
+	}
+}
@@ -0,0 +1,24 @@
+// Package migrationtest helps tests check the migrated schema against the
+// GORM models.
+package migrationtest
+
+import (
+	"testing"
+
+	"github.com/shuv1824/go-api-starter/internal/migration"
+	"gorm.io/gorm"
+)
+
+// AssertNoDrift fails t with every difference between the schema of db and
+// the tables models expect.
+func AssertNoDrift(t testing.TB, db *gorm.DB, models ...any) {
+	t.Helper()
+
+	problems, err := migration.CheckDrift(db, models...)
+	if err != nil {
+		t.Fatalf("failed to check schema drift: %v", err)
+	}
+	for _, problem := range problems {
+		t.Errorf("schema drift: %s", problem)
+	}
+}
@@ -0,0 +1,93 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/pressly/goose/v3"
+)
+
+// User search uses a different kind of index on every database, so this is
+// a Go migration instead of one SQL file per dialect. SQLite only has FTS5
+// when the driver is built with the sqlite_fts5 tag; without it the search
+// table is skipped and the user searcher falls back to pattern matching.
+func init() {
+	goose.AddMigrationContext(upAddUserSearch, downAddUserSearch)
+}
+
+var userSearchUp = map[string][]string{
+	"postgres": {
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		// Whole words and complete email addresses, matched by prefix.
+		`CREATE INDEX idx_users_search ON users USING GIN (to_tsvector('simple', name || ' ' || email))`,
+		// Fragments anywhere in a name or email.
+		`CREATE INDEX idx_users_search_trgm ON users USING GIN ((name || ' ' || email) gin_trgm_ops)`,
+	},
+	"mysql": {
+		`CREATE FULLTEXT INDEX idx_users_search ON users (name, email)`,
+	},
+	// An FTS5 table of existing users, kept in sync by triggers.
+	"sqlite": {
+		`CREATE VIRTUAL TABLE users_search USING fts5(id UNINDEXED, name, email, prefix='2 3')`,
+		`INSERT INTO users_search (id, name, email) SELECT id, name, email FROM users`,
+		`CREATE TRIGGER users_search_insert AFTER INSERT ON users BEGIN
+  INSERT INTO users_search (id, name, email) VALUES (new.id, new.name, new.email);
+END`,
+		`CREATE TRIGGER users_search_update AFTER UPDATE OF name, email ON users BEGIN
+  UPDATE users_search SET name = new.name, email = new.email WHERE id = old.id;
+END`,
+		`CREATE TRIGGER users_search_delete AFTER DELETE ON users BEGIN
+  DELETE FROM users_search WHERE id = old.id;
+END`,
+	},
+}
+
+var userSearchDown = map[string][]string{
+	"postgres": {
+		`DROP INDEX IF EXISTS idx_users_search_trgm`,
+		`DROP INDEX IF EXISTS idx_users_search`,
+	},
+	"mysql": {
+		`DROP INDEX idx_users_search ON users`,
+	},
+	"sqlite": {
+		`DROP TRIGGER IF EXISTS users_search_delete`,
+		`DROP TRIGGER IF EXISTS users_search_update`,
+		`DROP TRIGGER IF EXISTS users_search_insert`,
+		`DROP TABLE IF EXISTS users_search`,
+	},
+}
+
+func upAddUserSearch(ctx context.Context, tx *sql.Tx) error {
+	if Dialect(ctx) == "sqlite" {
+		var fts5 bool
+		if err := tx.QueryRowContext(ctx, "SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&fts5); err != nil {
+			return err
+		}
+		if !fts5 {
+			slog.Warn("SQLite was built without FTS5, skipping the user search table; build with -tags sqlite_fts5 to index user search")
+			return nil
+		}
+	}
+	return execAll(ctx, tx, userSearchUp)
+}
+
+func downAddUserSearch(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, userSearchDown)
+}
+
+func execAll(ctx context.Context, tx *sql.Tx, statements map[string][]string) error {
+	dialect := Dialect(ctx)
+	dialectStatements, ok := statements[dialect]
+	if !ok {
+		return fmt.Errorf("no statements for dialect %q", dialect)
+	}
+	for _, statement := range dialectStatements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/pressly/goose/v3"
+	"github.com/shuv1824/go-api-starter/internal/common/emailaddr"
+)
+
+// Emails become unique regardless of case. Existing addresses are
+// normalized the way the service now stores them and get an email_key, the
+// case folded form the unique index and lookups use. Both need Go: the
+// databases fold case differently, SQLite only for ASCII. Users whose
+// addresses share a key are reported rather than merged, which account to
+// keep is not the migration's call.
+func init() {
+	goose.AddMigrationContext(upNormalizeUserEmails, downNormalizeUserEmails)
+}
+
+// The default only fills the column for existing rows until they are
+// updated below; the application always sets the key.
+var userEmailKeyColumnUp = map[string][]string{
+	"postgres": {`ALTER TABLE users ADD COLUMN email_key VARCHAR(128) NOT NULL DEFAULT ''`},
+	"mysql":    {`ALTER TABLE users ADD COLUMN email_key VARCHAR(128) NOT NULL DEFAULT ''`},
+	"sqlite":   {`ALTER TABLE users ADD COLUMN email_key VARCHAR(128) NOT NULL DEFAULT ''`},
+}
+
+// The case-sensitive index on email is covered by the one on email_key.
+var userEmailKeyIndexUp = map[string][]string{
+	"postgres": {
+		`DROP INDEX IF EXISTS idx_users_email`,
+		`CREATE UNIQUE INDEX idx_users_email_key ON users (email_key)`,
+	},
+	"mysql": {
+		`DROP INDEX idx_users_email ON users`,
+		`CREATE UNIQUE INDEX idx_users_email_key ON users (email_key)`,
+	},
+	"sqlite": {
+		`DROP INDEX IF EXISTS idx_users_email`,
+		`CREATE UNIQUE INDEX idx_users_email_key ON users (email_key)`,
+	},
+}
+
+// Normalized addresses are kept on the way down, they are valid before this
+// migration too.
+var userEmailKeyDown = map[string][]string{
+	"postgres": {
+		`DROP INDEX IF EXISTS idx_users_email_key`,
+		`ALTER TABLE users DROP COLUMN email_key`,
+		`CREATE UNIQUE INDEX idx_users_email ON users (email)`,
+	},
+	"mysql": {
+		`DROP INDEX idx_users_email_key ON users`,
+		`ALTER TABLE users DROP COLUMN email_key`,
+		`CREATE UNIQUE INDEX idx_users_email ON users (email)`,
+	},
+	"sqlite": {
+		`DROP INDEX IF EXISTS idx_users_email_key`,
+		`ALTER TABLE users DROP COLUMN email_key`,
+		`CREATE UNIQUE INDEX idx_users_email ON users (email)`,
+	},
+}
+
+type userEmail struct {
+	id    string
+	email string
+	key   string
+}
+
+func upNormalizeUserEmails(ctx context.Context, tx *sql.Tx) error {
+	rows, err := tx.QueryContext(ctx, `SELECT id, email FROM users ORDER BY created_at, id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	// Soft deleted users are included, the index covers them as well. The
+	// local part keeps its case: lowercasing it is a setting of the service.
+	var users []userEmail
+	var keys []string
+	accounts := make(map[string][]string)
+	for rows.Next() {
+		var u userEmail
+		if err := rows.Scan(&u.id, &u.email); err != nil {
+			return err
+		}
+		u.email = emailaddr.Normalizer{}.Normalize(u.email)
+		u.key = emailaddr.Key(u.email)
+		users = append(users, u)
+
+		if _, ok := accounts[u.key]; !ok {
+			keys = append(keys, u.key)
+		}
+		accounts[u.key] = append(accounts[u.key], u.id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Only IDs are reported, the error ends up in deployment logs.
+	var duplicates []string
+	for _, key := range keys {
+		if ids := accounts[key]; len(ids) > 1 {
+			duplicates = append(duplicates, strings.Join(ids, ", "))
+		}
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("users share email addresses that only differ in case or formatting; merge or rename them and migrate again. Users by shared address:\n  %s",
+			strings.Join(duplicates, "\n  "))
+	}
+
+	if err := execAll(ctx, tx, userEmailKeyColumnUp); err != nil {
+		return err
+	}
+	update := `UPDATE users SET email = ?, email_key = ? WHERE id = ?`
+	if Dialect(ctx) == "postgres" {
+		update = `UPDATE users SET email = $1, email_key = $2 WHERE id = $3`
+	}
+	for _, u := range users {
+		if _, err := tx.ExecContext(ctx, update, u.email, u.key, u.id); err != nil {
+			return err
+		}
+	}
+
+	return execAll(ctx, tx, userEmailKeyIndexUp)
+}
+
+func downNormalizeUserEmails(ctx context.Context, tx *sql.Tx) error {
+	return execAll(ctx, tx, userEmailKeyDown)
+}
@@ -0,0 +1,18 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS users (
+  id CHAR(36) PRIMARY KEY,
+  name TEXT NOT NULL,
+  email VARCHAR(128) NOT NULL UNIQUE,
+  password VARCHAR(255) NOT NULL,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+  updated_at DATETIME(6),
+  deleted_at DATETIME(6)
+);
+
+CREATE INDEX idx_users_email ON users (email);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+  bucket VARCHAR(255) NOT NULL,
+  window_start BIGINT NOT NULL,
+  hits BIGINT NOT NULL,
+  expires_at DATETIME(6) NOT NULL,
+  PRIMARY KEY (bucket, window_start)
+);
+
+CREATE INDEX idx_rate_limits_expires_at ON rate_limits (expires_at);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS rate_limits;
@@ -0,0 +1,17 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  idempotency_key VARCHAR(255) PRIMARY KEY,
+  fingerprint VARCHAR(64) NOT NULL,
+  completed BOOLEAN NOT NULL DEFAULT FALSE,
+  status_code INTEGER NOT NULL DEFAULT 0,
+  header TEXT NOT NULL,
+  body LONGBLOB,
+  expires_at DATETIME(6) NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+
+-- +goose Down
+
+DROP TABLE IF EXISTS idempotency_keys;
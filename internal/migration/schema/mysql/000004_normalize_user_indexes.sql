@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- idx_users_email duplicated the UNIQUE constraint without enforcing it.
+DROP INDEX email ON users;
+DROP INDEX idx_users_email ON users;
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+-- +goose Down
+
+DROP INDEX idx_users_deleted_at ON users;
+DROP INDEX idx_users_email ON users;
+CREATE INDEX idx_users_email ON users (email);
+CREATE UNIQUE INDEX email ON users (email);
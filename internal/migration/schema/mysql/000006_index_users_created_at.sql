@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- Serves user listings paged by creation time; id breaks ties between users
+-- created at the same instant.
+CREATE INDEX idx_users_created_at ON users (created_at, id);
+
+-- +goose Down
+
+DROP INDEX idx_users_created_at ON users;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- role grants access beyond a user's own account: staff can list and search
+-- users, admin is staff for now.
+ALTER TABLE users ADD COLUMN role VARCHAR(16) NOT NULL DEFAULT 'user';
+
+-- +goose Down
+
+ALTER TABLE users DROP COLUMN role;
@@ -0,0 +1,16 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS rate_limits (
+  bucket VARCHAR(255) NOT NULL,
+  window_start BIGINT NOT NULL,
+  hits BIGINT NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (bucket, window_start)
+);
+
+CREATE INDEX idx_rate_limits_expires_at ON rate_limits (expires_at);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_rate_limits_expires_at;
+DROP TABLE IF EXISTS rate_limits;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- idx_users_email duplicated the UNIQUE constraint without enforcing it.
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS idx_users_email;
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+DROP INDEX IF EXISTS idx_users_email;
+CREATE INDEX idx_users_email ON users (email);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
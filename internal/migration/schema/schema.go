@@ -0,0 +1,20 @@
+// Package schema holds the database migrations. SQL migrations live in one
+// directory per database type; Go migrations are files in this package that
+// register themselves with goose and run for every database type.
+package schema
+
+import "context"
+
+type dialectKey struct{}
+
+// WithDialect returns a copy of ctx for migrating a database of dialect.
+func WithDialect(ctx context.Context, dialect string) context.Context {
+	return context.WithValue(ctx, dialectKey{}, dialect)
+}
+
+// Dialect returns the dialect being migrated, so Go migrations can run SQL
+// specific to it.
+func Dialect(ctx context.Context) string {
+	dialect, _ := ctx.Value(dialectKey{}).(string)
+	return dialect
+}
@@ -0,0 +1,19 @@
+-- +goose Up
+
+CREATE TABLE IF NOT EXISTS users (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  email VARCHAR(128) NOT NULL UNIQUE,
+  password VARCHAR(255) NOT NULL,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME,
+  deleted_at DATETIME
+);
+
+CREATE INDEX idx_users_email ON users (email);
+
+-- +goose Down
+
+DROP INDEX IF EXISTS idx_users_email;
+DROP TABLE IF EXISTS users;
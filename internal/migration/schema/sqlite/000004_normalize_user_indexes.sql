@@ -0,0 +1,44 @@
+-- +goose Up
+
+-- idx_users_email duplicated the UNIQUE constraint without enforcing it.
+-- SQLite cannot drop a column constraint, so the table is rebuilt.
+CREATE TABLE users_new (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  email VARCHAR(128) NOT NULL,
+  password VARCHAR(255) NOT NULL,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME,
+  deleted_at DATETIME
+);
+
+INSERT INTO users_new (id, name, email, password, is_active, created_at, updated_at, deleted_at)
+SELECT id, name, email, password, is_active, created_at, updated_at, deleted_at FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+
+CREATE UNIQUE INDEX idx_users_email ON users (email);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+
+-- +goose Down
+
+CREATE TABLE users_old (
+  id TEXT PRIMARY KEY,
+  name TEXT NOT NULL,
+  email VARCHAR(128) NOT NULL UNIQUE,
+  password VARCHAR(255) NOT NULL,
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME,
+  deleted_at DATETIME
+);
+
+INSERT INTO users_old (id, name, email, password, is_active, created_at, updated_at, deleted_at)
+SELECT id, name, email, password, is_active, created_at, updated_at, deleted_at FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+
+CREATE INDEX idx_users_email ON users (email);
@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- version is incremented by every update, so concurrent writers can detect
+-- that the row changed since they read it.
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose Down
+
+ALTER TABLE users DROP COLUMN version;
@@ -0,0 +1,160 @@
+// Package seed loads fixture sets, such as demo users for a development
+// database, through the domain services.
+package seed
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
+	"gopkg.in/yaml.v3"
+)
+
+// Each directory under data is a seed set; every .yaml, .yml and .json file
+// in it is loaded.
+//
+//go:embed data
+var embeddedFixtures embed.FS
+
+// Fixtures is the content of a seed set.
+type Fixtures struct {
+	Users []User `yaml:"users"`
+}
+
+type User struct {
+	Email    string `yaml:"email"`
+	Name     string `yaml:"name"`
+	Password string `yaml:"password"`
+	// IsActive defaults to true.
+	IsActive *bool `yaml:"is_active"`
+	// Role is user, staff or admin; user by default.
+	Role string `yaml:"role"`
+}
+
+// Result counts the records a seed set created and the ones that already
+// existed and were brought up to date.
+type Result struct {
+	Created  int
+	Existing int
+}
+
+// Sets returns the names of the embedded seed sets.
+func Sets() ([]string, error) {
+	entries, err := fs.ReadDir(embeddedFixtures, "data")
+	if err != nil {
+		return nil, err
+	}
+
+	var sets []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			sets = append(sets, entry.Name())
+		}
+	}
+	return sets, nil
+}
+
+// Load reads the embedded seed set named set.
+func Load(set string) (*Fixtures, error) {
+	sets, err := Sets()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(sets, set) {
+		return nil, fmt.Errorf("unknown seed set %q, available: %v", set, sets)
+	}
+
+	sub, err := fs.Sub(embeddedFixtures, path.Join("data", set))
+	if err != nil {
+		return nil, err
+	}
+	return LoadFS(sub)
+}
+
+// LoadFS reads every fixture file at the root of fsys, so tests can load
+// their own fixtures.
+func LoadFS(fsys fs.FS) (*Fixtures, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := &Fixtures{}
+	for _, entry := range entries {
+		switch path.Ext(entry.Name()) {
+		case ".yaml", ".yml", ".json":
+		default:
+			continue
+		}
+
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		// JSON is valid YAML, so one decoder reads both formats.
+		var file Fixtures
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to parse %s: %w", entry.Name(), err)
+		}
+
+		fixtures.Users = append(fixtures.Users, file.Users...)
+	}
+
+	return fixtures, nil
+}
+
+// Seeder loads fixtures through the domain services, so seeded data obeys
+// the same rules as data created through the API. Loading is idempotent:
+// records are matched by their natural key and only changed when they
+// differ from the fixture.
+type Seeder struct {
+	users core.Service
+}
+
+func NewSeeder(users core.Service) *Seeder {
+	return &Seeder{users: users}
+}
+
+func (s *Seeder) Seed(ctx context.Context, fixtures *Fixtures) (Result, error) {
+	var result Result
+
+	for i, fixture := range fixtures.Users {
+		req := core.UpsertUserRequest{
+			CreateUserRequest: core.CreateUserRequest{
+				Email:    fixture.Email,
+				Password: fixture.Password,
+				Name:     fixture.Name,
+			},
+			IsActive: fixture.IsActive == nil || *fixture.IsActive,
+			Role:     fixture.Role,
+		}
+
+		// Apply the validation rules of the registration endpoint.
+		if err := binding.Validator.ValidateStruct(&req); err != nil {
+			return result, fmt.Errorf("invalid user %d (%s): %w", i, fixture.Email, err)
+		}
+
+		_, created, err := s.users.Upsert(ctx, req)
+		if err != nil {
+			return result, fmt.Errorf("failed to seed user %s: %w", fixture.Email, err)
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Existing++
+		}
+	}
+
+	return result, nil
+}
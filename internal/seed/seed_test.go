@@ -0,0 +1,159 @@
+package seed
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/common/auth"
+	"github.com/shuv1824/go-api-starter/internal/common/emailaddr"
+	"github.com/shuv1824/go-api-starter/internal/common/tx"
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/internal/domains/user/core"
+	"github.com/shuv1824/go-api-starter/internal/domains/user/infra"
+	"github.com/shuv1824/go-api-starter/internal/migration"
+	"github.com/shuv1824/go-api-starter/pkg/database"
+)
+
+func setupService(t *testing.T) (core.Service, *infra.UserRepository) {
+	t.Helper()
+
+	cfg := config.Default().Database
+	cfg.Type = "sqlite"
+	cfg.DbName = ":memory:"
+
+	db, err := database.NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := migration.MigrateUp(db, cfg.Type, 0); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	repo := infra.NewRepository(db)
+	jwtService := auth.NewService("0123456789abcdef0123456789abcdef", time.Hour, time.Hour)
+	searcher, err := infra.NewSearcher(db, cfg.Type)
+	if err != nil {
+		t.Fatalf("failed to create searcher: %v", err)
+	}
+	return infra.NewService(repo, searcher, tx.NewManager(db, cfg.TxRetry), jwtService, emailaddr.Normalizer{}), repo
+}
+
+func TestSeeder_Idempotent(t *testing.T) {
+	service, repo := setupService(t)
+	ctx := context.Background()
+
+	fixtures, err := Load("test")
+	if err != nil {
+		t.Fatalf("failed to load fixtures: %v", err)
+	}
+	seeder := NewSeeder(service)
+
+	result, err := seeder.Seed(ctx, fixtures)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Created != 2 || result.Existing != 0 {
+		t.Errorf("expected 2 users created, got %+v", result)
+	}
+
+	result, err = seeder.Seed(ctx, fixtures)
+	if err != nil {
+		t.Fatalf("unexpected error seeding again: %v", err)
+	}
+	if result.Created != 0 || result.Existing != 2 {
+		t.Errorf("expected 2 existing users, got %+v", result)
+	}
+
+	if count, _ := repo.Count(ctx); count != 2 {
+		t.Errorf("expected 2 users, got %d", count)
+	}
+
+	inactive, err := repo.GetByEmail(ctx, "inactive@example.com")
+	if err != nil {
+		t.Fatalf("seeded user not found: %v", err)
+	}
+	if inactive.IsActive {
+		t.Error("expected is_active: false to be kept")
+	}
+	if inactive.Password == "password123" {
+		t.Error("expected password to be hashed")
+	}
+
+	if _, err := service.Login(ctx, core.LoginRequest{Email: "test@example.com", Password: "password123"}); err != nil {
+		t.Errorf("expected seeded user to log in, got %v", err)
+	}
+}
+
+func TestSeeder_UpdatesExisting(t *testing.T) {
+	service, repo := setupService(t)
+	ctx := context.Background()
+	seeder := NewSeeder(service)
+
+	fixtures := &Fixtures{Users: []User{{Email: "demo@example.com", Name: "Demo", Password: "first-password"}}}
+	if _, err := seeder.Seed(ctx, fixtures); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	fixtures.Users[0].Name = "Renamed"
+	fixtures.Users[0].Password = "second-password"
+	fixtures.Users[0].Role = core.RoleStaff
+	if _, err := seeder.Seed(ctx, fixtures); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	user, err := repo.GetByEmail(ctx, "demo@example.com")
+	if err != nil {
+		t.Fatalf("seeded user not found: %v", err)
+	}
+	if user.Name != "Renamed" {
+		t.Errorf("expected name to be updated, got %s", user.Name)
+	}
+	if user.Role != core.RoleStaff {
+		t.Errorf("expected role to be updated, got %s", user.Role)
+	}
+	if _, err := service.Login(ctx, core.LoginRequest{Email: "demo@example.com", Password: "second-password"}); err != nil {
+		t.Errorf("expected updated password to work, got %v", err)
+	}
+}
+
+func TestSeeder_Invalid(t *testing.T) {
+	service, _ := setupService(t)
+
+	fixtures := &Fixtures{Users: []User{{Email: "not-an-email", Name: "Demo", Password: "short"}}}
+	if _, err := NewSeeder(service).Seed(context.Background(), fixtures); err == nil {
+		t.Error("expected fixtures failing registration rules to be rejected")
+	}
+
+	fixtures = &Fixtures{Users: []User{{Email: "demo@example.com", Name: "Demo", Password: "first-password", Role: "root"}}}
+	if _, err := NewSeeder(service).Seed(context.Background(), fixtures); err == nil {
+		t.Error("expected an unknown role to be rejected")
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	fixtures, err := LoadFS(fstest.MapFS{
+		"users.yaml": {Data: []byte("users:\n  - email: a@example.com\n    name: A\n    password: password-a\n")},
+		"more.json":  {Data: []byte(`{"users": [{"email": "b@example.com", "name": "B", "password": "password-b"}]}`)},
+		"README.md":  {Data: []byte("ignored")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(fixtures.Users) != 2 {
+		t.Errorf("expected users from both files, got %+v", fixtures.Users)
+	}
+
+	if _, err := LoadFS(fstest.MapFS{"users.yaml": {Data: []byte("userz: []\n")}}); err == nil {
+		t.Error("expected unknown keys to be rejected")
+	}
+	if _, err := Load("nope"); err == nil {
+		t.Error("expected unknown seed set to be rejected")
+	}
+}
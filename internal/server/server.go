@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+)
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Server wraps http.Server with signal driven graceful shutdown and hooks
+// that release resources once in-flight requests have drained.
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	drainDelay      time.Duration
+
+	mu      sync.Mutex
+	hooks   []hook
+	onDrain []func()
+}
+
+func New(cfg config.ServerConfig, addr string, handler http.Handler) *Server {
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              addr,
+			Handler:           handler,
+			ReadTimeout:       cfg.ReadTimeout,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			MaxHeaderBytes:    cfg.MaxHeaderBytes,
+		},
+		shutdownTimeout: cfg.ShutdownTimeout,
+		drainDelay:      cfg.DrainDelay,
+	}
+}
+
+// OnDrain registers fn to run as soon as shutdown begins, before the server
+// stops accepting connections. It is used to fail readiness probes so load
+// balancers stop routing new traffic during the drain delay.
+func (s *Server) OnDrain(fn func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onDrain = append(s.onDrain, fn)
+}
+
+// OnShutdown registers fn to run after the HTTP server has stopped. Hooks run
+// in reverse registration order, like deferred calls, so resources created
+// first (the database) are released last.
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hooks = append(s.hooks, hook{name: name, fn: fn})
+}
+
+// Run serves until ctx is cancelled, then stops accepting connections, waits
+// up to the shutdown timeout for in-flight requests and runs the shutdown
+// hooks within the same deadline.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server listening", "addr", listener.Addr().String())
+		serveErr <- s.httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			s.runHooks(context.Background())
+			return err
+		}
+	case <-ctx.Done():
+		slog.Info("shutting down server", "timeout", s.shutdownTimeout)
+	}
+
+	s.mu.Lock()
+	onDrain := s.onDrain
+	s.mu.Unlock()
+	for _, fn := range onDrain {
+		fn()
+	}
+
+	if s.drainDelay > 0 {
+		time.Sleep(s.drainDelay)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	var errs []error
+	if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to drain connections: %w", err))
+	}
+	errs = append(errs, s.runHooks(shutdownCtx))
+
+	return errors.Join(errs...)
+}
+
+func (s *Server) runHooks(ctx context.Context) error {
+	s.mu.Lock()
+	hooks := s.hooks
+	s.hooks = nil
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if err := h.fn(ctx); err != nil {
+			slog.Error("shutdown hook failed", "hook", h.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+			continue
+		}
+		slog.Debug("shutdown hook completed", "hook", h.name)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+)
+
+func TestServer_RunShutsDownOnCancel(t *testing.T) {
+	srv := New(config.ServerConfig{ShutdownTimeout: time.Second}, "127.0.0.1:0", http.NotFoundHandler())
+
+	var order []string
+	srv.OnShutdown("database", func(ctx context.Context) error {
+		order = append(order, "database")
+		return nil
+	})
+	srv.OnShutdown("workers", func(ctx context.Context) error {
+		order = append(order, "workers")
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- srv.Run(ctx) }()
+
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	if want := []string{"workers", "database"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected hooks to run in order %v, got %v", want, order)
+	}
+}
+
+func TestServer_HookErrorsAreJoined(t *testing.T) {
+	srv := New(config.ServerConfig{ShutdownTimeout: time.Second}, "127.0.0.1:0", http.NotFoundHandler())
+
+	errFirst := errors.New("first")
+	ran := false
+	srv.OnShutdown("last", func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+	srv.OnShutdown("failing", func(ctx context.Context) error {
+		return errFirst
+	})
+
+	err := srv.runHooks(context.Background())
+	if !errors.Is(err, errFirst) {
+		t.Errorf("expected hook error to be returned, got %v", err)
+	}
+	if !ran {
+		t.Error("expected remaining hooks to run after a failure")
+	}
+}
@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	instrumentationName = "github.com/shuv1824/go-api-starter"
+	gormSpanKey         = "telemetry:span"
+)
+
+// GormPlugin creates a client span for every query GORM runs. Only the SQL
+// with placeholders is recorded; bound values, which may hold password
+// hashes and other personal data, never leave the process.
+type GormPlugin struct {
+	tracer trace.Tracer
+}
+
+func NewGormPlugin() *GormPlugin {
+	return &GormPlugin{tracer: otel.Tracer(instrumentationName)}
+}
+
+func (p *GormPlugin) Name() string {
+	return "telemetry:tracing"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	return errors.Join(
+		cb.Create().Before("gorm:create").Register("telemetry:before_create", p.before("create")),
+		cb.Create().After("gorm:create").Register("telemetry:after_create", p.after),
+		cb.Query().Before("gorm:query").Register("telemetry:before_query", p.before("query")),
+		cb.Query().After("gorm:query").Register("telemetry:after_query", p.after),
+		cb.Update().Before("gorm:update").Register("telemetry:before_update", p.before("update")),
+		cb.Update().After("gorm:update").Register("telemetry:after_update", p.after),
+		cb.Delete().Before("gorm:delete").Register("telemetry:before_delete", p.before("delete")),
+		cb.Delete().After("gorm:delete").Register("telemetry:after_delete", p.after),
+		cb.Row().Before("gorm:row").Register("telemetry:before_row", p.before("row")),
+		cb.Row().After("gorm:row").Register("telemetry:after_row", p.after),
+		cb.Raw().Before("gorm:raw").Register("telemetry:before_raw", p.before("raw")),
+		cb.Raw().After("gorm:raw").Register("telemetry:after_raw", p.after),
+	)
+}
+
+func (p *GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		ctx, span := p.tracer.Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.Statement.Context = ctx
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		semconv.DBCollectionName(db.Statement.Table),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -0,0 +1,81 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestTraceHandler_AddsTraceIDs(t *testing.T) {
+	provider := sdktrace.NewTracerProvider()
+	ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+	defer span.End()
+
+	var buf bytes.Buffer
+	log := slog.New(NewTraceHandler(slog.NewTextHandler(&buf, nil)))
+
+	log.InfoContext(ctx, "with span")
+	if !strings.Contains(buf.String(), "trace_id="+span.SpanContext().TraceID().String()) {
+		t.Errorf("expected trace_id in log record, got %q", buf.String())
+	}
+
+	buf.Reset()
+	log.Info("without span")
+	if strings.Contains(buf.String(), "trace_id") {
+		t.Errorf("expected no trace_id without a span, got %q", buf.String())
+	}
+}
+
+func TestGormPlugin_RecordsSanitizedSQL(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Use(&GormPlugin{tracer: provider.Tracer("test")}); err != nil {
+		t.Fatalf("failed to register plugin: %v", err)
+	}
+
+	type account struct {
+		ID       uint
+		Password string
+	}
+	if err := db.AutoMigrate(&account{}); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	recorder.Reset()
+
+	db.Create(&account{Password: "s3cr3t-hash"})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	if spans[0].Name() != "gorm.create" {
+		t.Errorf("unexpected span name %q", spans[0].Name())
+	}
+
+	var query string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == attribute.Key("db.query.text") {
+			query = attr.Value.AsString()
+		}
+	}
+	if !strings.Contains(query, "INSERT INTO `accounts`") {
+		t.Errorf("expected insert statement, got %q", query)
+	}
+	if strings.Contains(query, "s3cr3t-hash") {
+		t.Errorf("expected bound values to be left out, got %q", query)
+	}
+}
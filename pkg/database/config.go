@@ -0,0 +1,68 @@
+package database
+
+import (
+	"maps"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+)
+
+// ConnConfig is what a Driver connects with: the connection and pool
+// settings of the database configuration, with secrets resolved. It only
+// uses plain types so drivers can be registered from outside this module.
+type ConnConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	DbName   string
+	// SSLMode is one of disable, allow, prefer, require, verify-ca or
+	// verify-full.
+	SSLMode string
+	TLS     TLSFiles
+	// URL is a complete connection string in the driver's format. When set
+	// it replaces the settings above, Options and the timeouts.
+	URL     string
+	Options map[string]string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	ConnectTimeout   time.Duration
+	StatementTimeout time.Duration
+}
+
+// TLSFiles are the certificates for verifying the server and authenticating
+// the client.
+type TLSFiles struct {
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// connConfig copies the settings of cfg a driver sees.
+func connConfig(cfg *config.DatabaseConfig) *ConnConfig {
+	return &ConnConfig{
+		Host:     cfg.Host,
+		Port:     cfg.Port,
+		Username: cfg.Username,
+		Password: cfg.Password.Value(),
+		DbName:   cfg.DbName,
+		SSLMode:  cfg.SSLMode,
+		TLS: TLSFiles{
+			CAFile:   cfg.TLS.CAFile,
+			CertFile: cfg.TLS.CertFile,
+			KeyFile:  cfg.TLS.KeyFile,
+		},
+		URL:              cfg.URL.Value(),
+		Options:          maps.Clone(cfg.Options),
+		MaxOpenConns:     cfg.MaxOpenConns,
+		MaxIdleConns:     cfg.MaxIdleConns,
+		ConnMaxLifetime:  cfg.ConnMaxLifetime,
+		ConnMaxIdleTime:  cfg.ConnMaxIdleTime,
+		ConnectTimeout:   cfg.ConnectTimeout,
+		StatementTimeout: cfg.StatementTimeout,
+	}
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+)
+
+// defaultConnConfig returns what drivers see for the default configuration.
+func defaultConnConfig() ConnConfig {
+	cfg := config.Default().Database
+	return *connConfig(&cfg)
+}
+
+func TestConnConfig(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Password = config.NewSecret("s3cr3t")
+	cfg.URL = config.NewSecret("postgres://db/app")
+	cfg.TLS = config.TLSConfig{CAFile: "/certs/ca.pem"}
+	cfg.Options = map[string]string{"search_path": "app"}
+
+	conn := connConfig(&cfg)
+	if conn.Password != "s3cr3t" || conn.URL != "postgres://db/app" {
+		t.Errorf("expected secrets to be resolved, got %q and %q", conn.Password, conn.URL)
+	}
+	if conn.TLS.CAFile != "/certs/ca.pem" || conn.MaxOpenConns != cfg.MaxOpenConns {
+		t.Errorf("expected settings to be copied, got %+v", conn)
+	}
+
+	conn.Options["search_path"] = "public"
+	if cfg.Options["search_path"] != "app" {
+		t.Error("expected drivers to get their own copy of the options")
+	}
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"gorm.io/gorm"
+)
+
+// open connects through dialector, retrying with exponential backoff as
+// configured in cfg.Retry, and applies the connection pool settings of conn.
+func open(name string, dialector gorm.Dialector, cfg *config.DatabaseConfig, conn *ConnConfig) (*gorm.DB, error) {
+	attempts := max(cfg.Retry.Attempts, 1)
+	backoff := cfg.Retry.InitialBackoff
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var db *gorm.DB
+		db, err = connect(name, dialector, cfg.Log, conn)
+		if err == nil {
+			slog.Info("successfully connected to " + name + " database")
+			return db, nil
+		}
+		if attempt >= attempts {
+			break
+		}
+
+		slog.Warn("database not reachable, retrying",
+			"database", name, "attempt", attempt, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, cfg.Retry.MaxBackoff)
+	}
+
+	return nil, err
+}
+
+func connect(name string, dialector gorm.Dialector, logCfg config.DatabaseLogConfig, conn *ConnConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger: NewLogger(slog.Default(), logCfg),
+		// testConnection pings with a deadline instead.
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", name, err)
+	}
+
+	if err := testConnection(db, conn); err != nil {
+		if sqlDB, dbErr := db.DB(); dbErr == nil {
+			sqlDB.Close()
+		}
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func testConnection(db *gorm.DB, cfg *ConnConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	configurePool(sqlDB, cfg)
+	if err := ping(sqlDB, cfg.ConnectTimeout); err != nil {
+		return fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return nil
+}
+
+func configurePool(sqlDB *sql.DB, cfg *ConnConfig) {
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
+
+// ping checks the connection, giving up after timeout unless it is zero.
+func ping(sqlDB *sql.DB, timeout time.Duration) error {
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return sqlDB.PingContext(ctx)
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+)
+
+func TestNewDatabase_SQLiteMemoryPool(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Type = string(SQLite)
+	cfg.DbName = ":memory:"
+	cfg.StatementTimeout = time.Second
+
+	db, err := NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get database instance: %v", err)
+	}
+	defer sqlDB.Close()
+
+	if open := sqlDB.Stats().MaxOpenConnections; open != 1 {
+		t.Errorf("expected in-memory database to use a single connection, got %d", open)
+	}
+
+	// A second connection would see an empty database.
+	if err := db.Exec("CREATE TABLE items (id INTEGER)").Error; err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := db.Exec("INSERT INTO items (id) VALUES (1)").Error; err != nil {
+		t.Errorf("expected table to be visible on the pooled connection: %v", err)
+	}
+}
+
+func TestNewDatabase_RetriesThenFails(t *testing.T) {
+	cfg := config.Default().Database
+	cfg.Host = "127.0.0.1"
+	cfg.Port = 1
+	cfg.ConnectTimeout = time.Second
+	cfg.Retry = config.RetryConfig{
+		Attempts:       2,
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     10 * time.Millisecond,
+	}
+
+	start := time.Now()
+	if _, err := NewDatabase(&cfg); err == nil {
+		t.Fatal("expected error connecting to a closed port")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected connecting to give up quickly, took %v", elapsed)
+	}
+}
@@ -1,32 +1,47 @@
 package database
 
 import (
-    "fmt"
-    "github.com/shuv1824/go-api-starter/internal/config"
-    "gorm.io/gorm"
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"gorm.io/gorm"
 )
 
 type DatabaseType string
 
 const (
-    PostgreSQL DatabaseType = "postgres"
-    MySQL      DatabaseType = "mysql"
-    SQLite     DatabaseType = "sqlite"
+	PostgreSQL DatabaseType = "postgres"
+	MySQL      DatabaseType = "mysql"
+	SQLite     DatabaseType = "sqlite"
 )
 
+// NewDatabase connects to the database of the driver registered for
+// cfg.Type and, when replicas are configured, routes reads to them.
 func NewDatabase(cfg *config.DatabaseConfig) (*gorm.DB, error) {
-    var db Database
-
-    switch DatabaseType(cfg.Type) {
-    case PostgreSQL:
-        db = &PostgresDB{}
-    case MySQL:
-        db = &MySQLDB{}
-    case SQLite:
-        db = &SQLiteDB{}
-    default:
-        return nil, fmt.Errorf("unsupported database type: %s", cfg.Type)
-    }
-
-    return db.Connect(cfg)
+	driver, err := Lookup(cfg.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := connConfig(cfg)
+	if driver.Configure != nil {
+		driver.Configure(conn)
+	}
+
+	dialector, err := driver.Dialector(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := open(driver.Name, dialector, cfg, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Replicas.Hosts) > 0 {
+		if err := useReplicas(db, driver, cfg.Replicas, conn); err != nil {
+			Close(db)
+			return nil, err
+		}
+	}
+
+	return db, nil
 }
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Logger writes GORM's statements, slow queries and errors to a slog.Logger.
+// Records are logged with the query's context, so the request and trace IDs
+// added by the application's handler identify where a statement came from.
+type Logger struct {
+	log       *slog.Logger
+	level     *atomic.Int32
+	slow      *atomic.Int64
+	logParams bool
+}
+
+// NewLogger returns a GORM logger writing to log as configured by cfg, which
+// must have been validated.
+func NewLogger(log *slog.Logger, cfg config.DatabaseLogConfig) *Logger {
+	l := &Logger{
+		log:       log,
+		level:     new(atomic.Int32),
+		slow:      new(atomic.Int64),
+		logParams: cfg.LogParams,
+	}
+	l.SetLevel(cfg.Level)
+	l.SetSlowThreshold(cfg.SlowThreshold)
+	return l
+}
+
+// SetLevel changes the level of l and of the loggers derived from it with
+// WithContext or Session, but not with LogMode. Unknown levels are ignored.
+func (l *Logger) SetLevel(level string) {
+	if lvl, ok := parseLogLevel(level); ok {
+		l.level.Store(int32(lvl))
+	}
+}
+
+// SetSlowThreshold changes the duration above which statements are logged as
+// slow; zero disables the warnings.
+func (l *Logger) SetSlowThreshold(threshold time.Duration) {
+	l.slow.Store(int64(threshold))
+}
+
+func parseLogLevel(level string) (logger.LogLevel, bool) {
+	switch level {
+	case "silent":
+		return logger.Silent, true
+	case "error":
+		return logger.Error, true
+	case "warn":
+		return logger.Warn, true
+	case "info":
+		return logger.Info, true
+	}
+	return 0, false
+}
+
+// LogMode returns a copy of l logging at level, as used by db.Debug().
+func (l *Logger) LogMode(level logger.LogLevel) logger.Interface {
+	derived := *l
+	derived.level = new(atomic.Int32)
+	derived.level.Store(int32(level))
+	return &derived
+}
+
+func (l *Logger) enabled(level logger.LogLevel) bool {
+	return logger.LogLevel(l.level.Load()) >= level
+}
+
+func (l *Logger) Info(ctx context.Context, msg string, data ...any) {
+	if l.enabled(logger.Info) {
+		l.log.InfoContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *Logger) Warn(ctx context.Context, msg string, data ...any) {
+	if l.enabled(logger.Warn) {
+		l.log.WarnContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+func (l *Logger) Error(ctx context.Context, msg string, data ...any) {
+	if l.enabled(logger.Error) {
+		l.log.ErrorContext(ctx, fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a finished statement: failures at error level, statements
+// slower than the threshold as warnings and everything else at info level.
+// A record not found is an expected outcome, not a failure.
+func (l *Logger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if !l.enabled(logger.Error) {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	slow := time.Duration(l.slow.Load())
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+
+	var (
+		level = slog.LevelInfo
+		msg   = "sql query"
+	)
+	switch {
+	case failed:
+		level, msg = slog.LevelError, "sql query failed"
+	case slow > 0 && elapsed > slow && l.enabled(logger.Warn):
+		level, msg = slog.LevelWarn, "slow sql query"
+	case l.enabled(logger.Info):
+	default:
+		return
+	}
+	if !l.log.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Duration("duration", elapsed),
+	}
+	if rows >= 0 {
+		attrs = append(attrs, slog.Int64("rows", rows))
+	}
+	if failed {
+		attrs = append(attrs, slog.Any("error", err))
+	} else if level == slog.LevelWarn {
+		attrs = append(attrs, slog.Duration("threshold", slow))
+	}
+	l.log.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// ParamsFilter drops bound values from logged statements unless logging
+// them is enabled, leaving their placeholders in the SQL.
+func (l *Logger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	if l.logParams {
+		return sql, params
+	}
+	return sql, nil
+}
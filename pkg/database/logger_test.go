@@ -0,0 +1,122 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/common/requestid"
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"github.com/shuv1824/go-api-starter/internal/telemetry"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// openLoggedSQLite opens an in-memory database logging as JSON to the
+// returned buffer.
+func openLoggedSQLite(t *testing.T, cfg config.DatabaseLogConfig) (*gorm.DB, *Logger, *bytes.Buffer) {
+	t.Helper()
+	var buf bytes.Buffer
+	handler := telemetry.NewTraceHandler(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	sqlLogger := NewLogger(slog.New(handler), cfg)
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: sqlLogger})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Exec("CREATE TABLE accounts (id INTEGER, password TEXT)").Error; err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	buf.Reset()
+	return db, sqlLogger, &buf
+}
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogger_RedactsParams(t *testing.T) {
+	db, _, buf := openLoggedSQLite(t, config.DatabaseLogConfig{Level: "info"})
+
+	ctx := requestid.NewContext(context.Background(), "req-123")
+	if err := db.WithContext(ctx).Exec("INSERT INTO accounts (id, password) VALUES (?, ?)", 1, "s3cr3t-hash").Error; err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	records := logRecords(t, buf)
+	if len(records) != 1 {
+		t.Fatalf("expected 1 record, got %d: %s", len(records), buf)
+	}
+	record := records[0]
+	if record["level"] != "INFO" || record["msg"] != "sql query" {
+		t.Errorf("unexpected record %v", record)
+	}
+	if sql := record["sql"].(string); !strings.Contains(sql, "VALUES (?, ?)") || strings.Contains(sql, "s3cr3t-hash") {
+		t.Errorf("expected bound values to be redacted, got %q", sql)
+	}
+	if record["request_id"] != "req-123" {
+		t.Errorf("expected request_id from context, got %v", record["request_id"])
+	}
+	if record["rows"] != float64(1) {
+		t.Errorf("expected 1 row, got %v", record["rows"])
+	}
+}
+
+func TestLogger_LogParams(t *testing.T) {
+	db, _, buf := openLoggedSQLite(t, config.DatabaseLogConfig{Level: "info", LogParams: true})
+
+	db.Exec("INSERT INTO accounts (id, password) VALUES (?, ?)", 1, "visible")
+
+	if !strings.Contains(buf.String(), "visible") {
+		t.Errorf("expected bound values with log_params, got %s", buf)
+	}
+}
+
+func TestLogger_Levels(t *testing.T) {
+	db, sqlLogger, buf := openLoggedSQLite(t, config.DatabaseLogConfig{Level: "warn", SlowThreshold: time.Hour})
+
+	var count int64
+	db.Table("accounts").Count(&count)
+	var id int
+	db.Table("accounts").Select("id").Take(&id)
+	if buf.Len() != 0 {
+		t.Errorf("expected fast queries and missing records not to be logged at warn, got %s", buf)
+	}
+
+	db.Exec("SELECT * FROM missing_table")
+	records := logRecords(t, buf)
+	if len(records) != 1 || records[0]["level"] != "ERROR" || records[0]["error"] == nil {
+		t.Errorf("expected failed query to be logged as error, got %s", buf)
+	}
+
+	buf.Reset()
+	sqlLogger.SetSlowThreshold(time.Nanosecond)
+	db.Table("accounts").Count(&count)
+	records = logRecords(t, buf)
+	if len(records) != 1 || records[0]["msg"] != "slow sql query" || records[0]["duration"] == nil || records[0]["rows"] == nil {
+		t.Errorf("expected slow query warning with duration and rows, got %s", buf)
+	}
+
+	buf.Reset()
+	sqlLogger.SetLevel("silent")
+	db.Exec("SELECT * FROM missing_table")
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing to be logged when silent, got %s", buf)
+	}
+}
@@ -2,54 +2,97 @@ package database
 
 import (
 	"fmt"
-	"log/slog"
+	"maps"
+	"net"
+	"strconv"
+	"time"
 
-	"github.com/shuv1824/go-api-starter/internal/config"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-type MySQLDB struct{}
-
-func (m *MySQLDB) Connect(cfg *config.DatabaseConfig) (*gorm.DB, error) {
-	dialector := m.GetDialector(cfg)
-	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+func init() {
+	Register(string(MySQL), Driver{
+		Name:    "MySQL",
+		Dialect: "mysql",
+		Dialector: func(cfg *ConnConfig) (gorm.Dialector, error) {
+			dsn, err := mysqlDSN(cfg)
+			if err != nil {
+				return nil, err
+			}
+			return mysql.Open(dsn), nil
+		},
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to MySQL: %w", err)
+}
+
+// mysqlDSN builds a go-sql-driver DSN. A host starting with a slash is a
+// unix socket.
+func mysqlDSN(cfg *ConnConfig) (string, error) {
+	if cfg.URL != "" {
+		return cfg.URL, nil
 	}
 
-	if err := m.testConnection(db); err != nil {
-		return nil, err
+	dsn := mysqldriver.NewConfig()
+	dsn.User = cfg.Username
+	dsn.Passwd = cfg.Password
+	dsn.DBName = cfg.DbName
+	if isUnixSocket(cfg.Host) {
+		dsn.Net, dsn.Addr = "unix", cfg.Host
+	} else {
+		dsn.Net, dsn.Addr = "tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	}
+	dsn.ParseTime = true
+	dsn.Loc = time.Local
+	dsn.Timeout = cfg.ConnectTimeout
+	dsn.Params = map[string]string{"charset": "utf8mb4"}
+	if cfg.StatementTimeout > 0 {
+		// Applied to the session with SET; MySQL enforces it for SELECT.
+		dsn.Params["max_execution_time"] = strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10)
 	}
 
-	slog.Info("successfully connected to MySQL database")
-	return db, nil
-}
+	var err error
+	if dsn.TLSConfig, err = mysqlTLS(cfg, dsn.Addr); err != nil {
+		return "", err
+	}
+	dsn.AllowFallbackToPlaintext = cfg.SSLMode == "allow" || cfg.SSLMode == "prefer"
 
-func (m *MySQLDB) GetDialector(cfg *config.DatabaseConfig) gorm.Dialector {
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.DbName)
-	return mysql.Open(dsn)
+	// Parameters are applied in order and options are written last, so
+	// they also override loc, parseTime and the like.
+	maps.Copy(dsn.Params, cfg.Options)
+	formatted := dsn.FormatDSN()
+	if _, err := mysqldriver.ParseDSN(formatted); err != nil {
+		return "", fmt.Errorf("invalid mysql options: %w", err)
+	}
+	return formatted, nil
 }
 
-func (m *MySQLDB) testConnection(db *gorm.DB) error {
-	sqlDB, err := db.DB()
-	if err != nil {
-		return fmt.Errorf("failed to get database instance: %w", err)
+// mysqlTLS returns the tls parameter for cfg.SSLMode, registering a TLS
+// config with the driver when certificates are involved. With allow and
+// prefer the driver falls back to plaintext when the server has no TLS.
+func mysqlTLS(cfg *ConnConfig, addr string) (string, error) {
+	if cfg.SSLMode == "" || cfg.SSLMode == "disable" {
+		return "", nil
 	}
 
-	// Configure connection pool
-	// sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	// sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	// sqlDB.SetConnMaxLifetime(cfg.MaxLifetime)
-
-	// Test connection
-	if err := sqlDB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	if cfg.TLS == (TLSFiles{}) {
+		switch cfg.SSLMode {
+		case "allow", "prefer":
+			return "preferred", nil
+		case "require":
+			return "skip-verify", nil
+		case "verify-full":
+			return "true", nil
+		}
 	}
 
-	return nil
+	tlsConfig, err := newTLSConfig(cfg.SSLMode, cfg.TLS, cfg.Host)
+	if err != nil {
+		return "", err
+	}
+	name := "app-" + addr
+	if err := mysqldriver.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", fmt.Errorf("failed to register tls config: %w", err)
+	}
+	return name, nil
 }
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+func mysqlTestConfig() ConnConfig {
+	cfg := defaultConnConfig()
+	cfg.Port = 3306
+	cfg.Username = "root"
+	cfg.Password = "s3cr3t"
+	return cfg
+}
+
+func parseMySQLDSN(t *testing.T, cfg *ConnConfig) *mysqldriver.Config {
+	t.Helper()
+	dsn, err := mysqlDSN(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parsed, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("failed to parse DSN %q: %v", dsn, err)
+	}
+	return parsed
+}
+
+func TestMySQLDSN_Defaults(t *testing.T) {
+	cfg := mysqlTestConfig()
+	cfg.StatementTimeout = 2 * time.Second
+
+	parsed := parseMySQLDSN(t, &cfg)
+
+	if parsed.Net != "tcp" || parsed.Addr != "localhost:3306" {
+		t.Errorf("expected tcp localhost:3306, got %s %s", parsed.Net, parsed.Addr)
+	}
+	if parsed.User != "root" || parsed.Passwd != "s3cr3t" || parsed.DBName != "gostarter" {
+		t.Errorf("unexpected credentials or database: %s %s %s", parsed.User, parsed.Passwd, parsed.DBName)
+	}
+	if !parsed.ParseTime || parsed.Loc != time.Local {
+		t.Errorf("expected parseTime and local time, got %v %v", parsed.ParseTime, parsed.Loc)
+	}
+	if parsed.Timeout != 5*time.Second {
+		t.Errorf("expected connect timeout 5s, got %v", parsed.Timeout)
+	}
+	if parsed.Params["max_execution_time"] != "2000" {
+		t.Errorf("expected max_execution_time 2000, got %v", parsed.Params)
+	}
+	if parsed.TLSConfig != "" {
+		t.Errorf("expected no TLS, got %q", parsed.TLSConfig)
+	}
+}
+
+func TestMySQLDSN_EscapesPassword(t *testing.T) {
+	cfg := mysqlTestConfig()
+	cfg.Password = "p@ss/w:rd?&x=1"
+
+	if got := parseMySQLDSN(t, &cfg).Passwd; got != cfg.Password {
+		t.Errorf("expected password to round trip, got %q", got)
+	}
+}
+
+func TestMySQLDSN_OptionsAndSocket(t *testing.T) {
+	cfg := mysqlTestConfig()
+	cfg.Host = "/var/run/mysqld/mysqld.sock"
+	cfg.Options = map[string]string{
+		"loc":       "UTC",
+		"charset":   "latin1",
+		"time_zone": "'+00:00'",
+	}
+
+	parsed := parseMySQLDSN(t, &cfg)
+
+	if parsed.Net != "unix" || parsed.Addr != cfg.Host {
+		t.Errorf("expected unix socket %s, got %s %s", cfg.Host, parsed.Net, parsed.Addr)
+	}
+	if parsed.Loc != time.UTC {
+		t.Errorf("expected loc option to override the default, got %v", parsed.Loc)
+	}
+	if parsed.Params["time_zone"] != "'+00:00'" {
+		t.Errorf("expected time_zone session variable, got %v", parsed.Params)
+	}
+
+	dsn, _ := mysqlDSN(&cfg)
+	if !strings.Contains(dsn, "charset=latin1") || strings.Contains(dsn, "utf8mb4") {
+		t.Errorf("expected charset option to replace the default, got %s", dsn)
+	}
+
+	cfg.Options = map[string]string{"parseTime": "maybe"}
+	if _, err := mysqlDSN(&cfg); err == nil {
+		t.Error("expected error for invalid option")
+	}
+}
+
+func TestMySQLDSN_TLS(t *testing.T) {
+	ca := writeTestCA(t)
+
+	tests := []struct {
+		name      string
+		mode      string
+		tls       TLSFiles
+		want      string
+		plaintext bool
+	}{
+		{name: "disable", mode: "disable", want: ""},
+		{name: "prefer", mode: "prefer", want: "preferred", plaintext: true},
+		{name: "prefer with ca", mode: "prefer", tls: TLSFiles{CAFile: ca}, want: "app-localhost:3306", plaintext: true},
+		{name: "require", mode: "require", want: "skip-verify"},
+		{name: "verify-full", mode: "verify-full", want: "true"},
+		{name: "verify-ca", mode: "verify-ca", tls: TLSFiles{CAFile: ca}, want: "app-localhost:3306"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := mysqlTestConfig()
+			cfg.SSLMode = tt.mode
+			cfg.TLS = tt.tls
+
+			parsed := parseMySQLDSN(t, &cfg)
+			if parsed.TLSConfig != tt.want {
+				t.Errorf("expected tls %q, got %q", tt.want, parsed.TLSConfig)
+			}
+			if parsed.AllowFallbackToPlaintext != tt.plaintext {
+				t.Errorf("expected plaintext fallback %v, got %v", tt.plaintext, parsed.AllowFallbackToPlaintext)
+			}
+		})
+	}
+
+	cfg := mysqlTestConfig()
+	cfg.SSLMode = "verify-full"
+	cfg.TLS = TLSFiles{CAFile: "/does/not/exist.pem"}
+	if _, err := mysqlDSN(&cfg); err == nil {
+		t.Error("expected error for missing ca_file")
+	}
+}
@@ -2,56 +2,95 @@ package database
 
 import (
 	"fmt"
-	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
 
-	"github.com/shuv1824/go-api-starter/internal/config"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-type PostgresDB struct{}
-
-func (p *PostgresDB) Connect(cfg *config.DatabaseConfig) (*gorm.DB, error) {
-	dialector := p.GetDialector(cfg)
-	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+func init() {
+	Register(string(PostgreSQL), Driver{
+		Name:    "PostgreSQL",
+		Dialect: "postgres",
+		Dialector: func(cfg *ConnConfig) (gorm.Dialector, error) {
+			return postgres.Open(postgresDSN(cfg)), nil
+		},
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+}
+
+// postgresDSN builds a key/value connection string. A host starting with a
+// slash is the directory of a unix socket.
+func postgresDSN(cfg *ConnConfig) string {
+	if cfg.URL != "" {
+		return cfg.URL
 	}
 
-	if err := p.testConnection(db); err != nil {
-		return nil, err
+	var params dsnParams
+	params.set("host", cfg.Host)
+	params.set("port", strconv.Itoa(cfg.Port))
+	params.set("user", cfg.Username)
+	params.set("password", cfg.Password)
+	params.set("dbname", cfg.DbName)
+	params.set("sslmode", cfg.SSLMode)
+	params.set("sslrootcert", cfg.TLS.CAFile)
+	params.set("sslcert", cfg.TLS.CertFile)
+	params.set("sslkey", cfg.TLS.KeyFile)
+	params.set("TimeZone", "UTC")
+	if cfg.ConnectTimeout > 0 {
+		params.set("connect_timeout", strconv.Itoa(max(int(cfg.ConnectTimeout.Seconds()), 1)))
+	}
+	if cfg.StatementTimeout > 0 {
+		params.set("statement_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+	for _, key := range slices.Sorted(maps.Keys(cfg.Options)) {
+		params.set(key, cfg.Options[key])
 	}
 
-	slog.Info("successfully connected to PostgreSQL database")
-	return db, nil
+	return params.String()
 }
 
-func (p *PostgresDB) GetDialector(cfg *config.DatabaseConfig) gorm.Dialector {
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=UTC",
-		cfg.Host, cfg.Username, cfg.Password, cfg.DbName, cfg.Port, cfg.SSLMode,
-	)
-	return postgres.Open(dsn)
+// dsnParams keeps key/value parameters in order; setting a key again
+// replaces its value in place.
+type dsnParams struct {
+	keys   []string
+	values map[string]string
 }
 
-func (p *PostgresDB) testConnection(db *gorm.DB) error {
-	sqlDB, err := db.DB()
-	if err != nil {
-		return fmt.Errorf("failed to get database instance: %w", err)
+func (p *dsnParams) set(key, value string) {
+	if p.values == nil {
+		p.values = map[string]string{}
 	}
+	if _, ok := p.values[key]; !ok {
+		p.keys = append(p.keys, key)
+	}
+	p.values[key] = value
+}
 
-	// Configure connection pool
-	// sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
-	// sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
-	// sqlDB.SetConnMaxLifetime(cfg.MaxLifetime)
-
-	// Test connection
-	if err := sqlDB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+// String formats the parameters, leaving out empty ones and quoting values
+// as libpq expects.
+func (p *dsnParams) String() string {
+	var b strings.Builder
+	for _, key := range p.keys {
+		value := p.values[key]
+		if value == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		fmt.Fprintf(&b, "%s=%s", key, quoteDSNValue(value))
 	}
+	return b.String()
+}
 
-	return nil
+func quoteDSNValue(value string) string {
+	if !strings.ContainsAny(value, " \t\n'\\") {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
 }
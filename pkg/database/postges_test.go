@@ -0,0 +1,92 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestPostgresDSN(t *testing.T) {
+	base := defaultConnConfig()
+	base.Username = "app"
+	base.Password = "s3cr3t"
+
+	tests := []struct {
+		name   string
+		modify func(cfg *ConnConfig)
+		want   string
+	}{
+		{
+			name:   "defaults",
+			modify: func(cfg *ConnConfig) {},
+			want:   "host=localhost port=5432 user=app password=s3cr3t dbname=gostarter sslmode=disable TimeZone=UTC connect_timeout=5",
+		},
+		{
+			name: "quoted password",
+			modify: func(cfg *ConnConfig) {
+				cfg.Password = `it's a \secret`
+				cfg.ConnectTimeout = 0
+			},
+			want: `host=localhost port=5432 user=app password='it\'s a \\secret' dbname=gostarter sslmode=disable TimeZone=UTC`,
+		},
+		{
+			name: "options override defaults",
+			modify: func(cfg *ConnConfig) {
+				cfg.ConnectTimeout = 0
+				cfg.StatementTimeout = 2 * time.Second
+				cfg.Options = map[string]string{
+					"TimeZone":         "Europe/Berlin",
+					"search_path":      "app,public",
+					"application_name": "api server",
+				}
+			},
+			want: "host=localhost port=5432 user=app password=s3cr3t dbname=gostarter sslmode=disable TimeZone=Europe/Berlin statement_timeout=2000 application_name='api server' search_path=app,public",
+		},
+		{
+			name: "tls and unix socket",
+			modify: func(cfg *ConnConfig) {
+				cfg.Host = "/var/run/postgresql"
+				cfg.ConnectTimeout = 0
+				cfg.SSLMode = "verify-full"
+				cfg.TLS = TLSFiles{CAFile: "/certs/ca.pem", CertFile: "/certs/client.pem", KeyFile: "/certs/client.key"}
+			},
+			want: "host=/var/run/postgresql port=5432 user=app password=s3cr3t dbname=gostarter sslmode=verify-full sslrootcert=/certs/ca.pem sslcert=/certs/client.pem sslkey=/certs/client.key TimeZone=UTC",
+		},
+		{
+			name: "url",
+			modify: func(cfg *ConnConfig) {
+				cfg.URL = "postgres://app:p%40ss@db:5433/app?sslmode=require"
+			},
+			want: "postgres://app:p%40ss@db:5433/app?sslmode=require",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := base
+			tt.modify(&cfg)
+
+			if got := postgresDSN(&cfg); got != tt.want {
+				t.Errorf("expected DSN\n%s\ngot\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestPostgresDSN_PasswordRoundTrip(t *testing.T) {
+	cfg := defaultConnConfig()
+	cfg.Username = "app"
+	cfg.Password = `p@ss w'rd\ =;`
+
+	parsed, err := pgconn.ParseConfig(postgresDSN(&cfg))
+	if err != nil {
+		t.Fatalf("failed to parse DSN: %v", err)
+	}
+	if parsed.Password != cfg.Password {
+		t.Errorf("expected password to survive quoting, got %q", parsed.Password)
+	}
+	if parsed.RuntimeParams["TimeZone"] != "UTC" {
+		t.Errorf("expected TimeZone runtime parameter, got %v", parsed.RuntimeParams)
+	}
+}
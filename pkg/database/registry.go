@@ -0,0 +1,71 @@
+package database
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"gorm.io/gorm"
+)
+
+// Driver describes how to connect to one type of database. Drivers register
+// themselves with Register, usually from an init function, and are selected
+// by database.type.
+type Driver struct {
+	// Name is used in logs, e.g. "PostgreSQL".
+	Name string
+	// Dialector builds the GORM dialector for cfg.
+	Dialector func(cfg *ConnConfig) (gorm.Dialector, error)
+	// Dialect selects the migrations, the goose dialect and the migration
+	// lock: postgres, mysql or sqlite. A driver for a compatible database
+	// reuses the dialect it is compatible with.
+	Dialect string
+	// Embedded databases are opened in process from a file and take no
+	// host, port, TLS or replicas.
+	Embedded bool
+	// Configure, if set, adjusts a copy of the settings before connecting.
+	Configure func(cfg *ConnConfig)
+}
+
+var (
+	driversMu sync.RWMutex
+	drivers   = map[string]Driver{}
+)
+
+// Register makes driver available as database type typ. It panics if typ is
+// registered twice or driver has no dialector, like sql.Register.
+func Register(typ string, driver Driver) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	if driver.Dialector == nil {
+		panic("database: Register driver " + typ + " without a dialector")
+	}
+	if _, dup := drivers[typ]; dup {
+		panic("database: Register called twice for driver " + typ)
+	}
+	drivers[typ] = driver
+	config.RegisterDatabaseType(typ, driver.Embedded)
+}
+
+// Lookup returns the driver registered for typ.
+func Lookup(typ string) (Driver, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	driver, ok := drivers[typ]
+	if !ok {
+		return Driver{}, fmt.Errorf("unsupported database type: %s", typ)
+	}
+	return driver, nil
+}
+
+// Types returns the registered database types, sorted.
+func Types() []string {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	return slices.Sorted(maps.Keys(drivers))
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+)
+
+func TestRegistry(t *testing.T) {
+	for _, typ := range []DatabaseType{PostgreSQL, MySQL, SQLite} {
+		if !slices.Contains(Types(), string(typ)) {
+			t.Errorf("expected built-in driver %s to be registered", typ)
+		}
+	}
+
+	if _, err := Lookup("oracle"); err == nil {
+		t.Error("expected error for unregistered type")
+	}
+
+	cfg := config.Default().Database
+	cfg.Type = "oracle"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "unsupported database type") {
+		t.Errorf("expected unregistered type to be invalid, got %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected registering a type twice to panic")
+			}
+		}()
+		Register(string(SQLite), Driver{Dialector: drivers[string(SQLite)].Dialector})
+	}()
+}
+
+func TestNewDatabase_Configure(t *testing.T) {
+	sqlite, err := Lookup(string(SQLite))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sqlite.Configure = func(cfg *ConnConfig) {
+		cfg.MaxOpenConns = 3
+	}
+	if _, err := Lookup("test-configure"); err != nil {
+		Register("test-configure", sqlite)
+	}
+
+	cfg := config.Default().Database
+	cfg.Type = "test-configure"
+	cfg.DbName = t.TempDir() + "/test.db"
+	db, err := NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer Close(db)
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("failed to get database instance: %v", err)
+	}
+	if sqlDB.Stats().MaxOpenConnections != 3 {
+		t.Error("expected Configure to adjust the pool settings")
+	}
+	if cfg.MaxOpenConns != config.Default().Database.MaxOpenConns {
+		t.Error("expected Configure to work on a copy of the settings")
+	}
+}
@@ -0,0 +1,290 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"gorm.io/gorm"
+)
+
+const replicaRouterName = "app:replicas"
+
+type readYourWritesKey struct{}
+
+// WithReadYourWrites returns a context whose queries go to the primary, so
+// they see writes the replicas may not have applied yet.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readYourWritesKey{}, true)
+}
+
+func readYourWrites(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(readYourWritesKey{}).(bool)
+	return v
+}
+
+type rawReplicaReadsKey struct{}
+
+// WithRawReplicaReads returns a context whose raw SELECT statements may go
+// to a replica. Raw SQL stays on the primary otherwise, because a SELECT can
+// change state or depend on the session, as with set_config, nextval or
+// GET_LOCK. Locking reads stay on the primary either way.
+func WithRawReplicaReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, rawReplicaReadsKey{}, true)
+}
+
+func rawReplicaReads(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	v, _ := ctx.Value(rawReplicaReadsKey{}).(bool)
+	return v
+}
+
+// Close stops the replica health checks and closes the primary and replica
+// connection pools.
+func Close(db *gorm.DB) error {
+	var errs []error
+	if router, ok := db.Config.Plugins[replicaRouterName].(*replicaRouter); ok {
+		errs = append(errs, router.close())
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+	return errors.Join(append(errs, sqlDB.Close())...)
+}
+
+// useReplicas routes reads of db to the replicas in cfg, opened with the
+// dialector of driver and the settings of the primary in conn. Replicas that
+// cannot be reached are left out of rotation until a health check succeeds.
+func useReplicas(db *gorm.DB, driver Driver, cfg config.ReplicasConfig, conn *ConnConfig) error {
+	router := newReplicaRouter(cfg, conn.ConnectTimeout)
+	for _, addr := range cfg.Hosts {
+		replicaCfg := *conn
+		replicaCfg.Host, replicaCfg.Port = splitHostPort(addr, conn.Port)
+		router.add(addr, func() (*sql.DB, error) {
+			dialector, err := driver.Dialector(&replicaCfg)
+			if err != nil {
+				return nil, err
+			}
+			replicaDB, err := gorm.Open(dialector, &gorm.Config{Logger: db.Logger, DisableAutomaticPing: true})
+			if err != nil {
+				return nil, err
+			}
+			sqlDB, err := replicaDB.DB()
+			if err != nil {
+				return nil, err
+			}
+			configurePool(sqlDB, conn)
+			return sqlDB, nil
+		})
+	}
+
+	if err := db.Use(router); err != nil {
+		return fmt.Errorf("failed to register replicas: %w", err)
+	}
+	router.start()
+	return nil
+}
+
+// splitHostPort splits a replica address, defaulting to port when addr has
+// none. Addresses are validated with the configuration.
+func splitHostPort(addr string, port int) (string, int) {
+	host, rawPort, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr, port
+	}
+	if p, err := strconv.Atoi(rawPort); err == nil {
+		port = p
+	}
+	return host, port
+}
+
+type replica struct {
+	addr    string
+	open    func() (*sql.DB, error)
+	pool    atomic.Pointer[sql.DB]
+	healthy atomic.Bool
+}
+
+// check pings the replica, opening its pool first if that failed before.
+func (r *replica) check(timeout time.Duration) error {
+	pool := r.pool.Load()
+	if pool == nil {
+		var err error
+		if pool, err = r.open(); err != nil {
+			return err
+		}
+		r.pool.Store(pool)
+	}
+	return ping(pool, timeout)
+}
+
+// replicaRouter is a GORM plugin sending reads made outside transactions to
+// a healthy replica. Writes, transactions, locking reads, reads with
+// WithReadYourWrites and raw SQL without WithRawReplicaReads stay on the
+// primary, as does everything while no replica is healthy.
+type replicaRouter struct {
+	replicas []*replica
+	random   bool
+	next     atomic.Uint64
+
+	interval time.Duration
+	timeout  time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newReplicaRouter(cfg config.ReplicasConfig, timeout time.Duration) *replicaRouter {
+	return &replicaRouter{
+		random:   cfg.Policy == "random",
+		interval: cfg.HealthCheckInterval,
+		timeout:  timeout,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+func (r *replicaRouter) add(addr string, open func() (*sql.DB, error)) {
+	rep := &replica{addr: addr, open: open}
+	// Counted as healthy until the first check, so a replica that is down
+	// on startup is logged; pick skips it until its pool is open.
+	rep.healthy.Store(true)
+	r.replicas = append(r.replicas, rep)
+}
+
+func (r *replicaRouter) Name() string {
+	return replicaRouterName
+}
+
+func (r *replicaRouter) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Query().Before("gorm:query").Register(replicaRouterName, r.route); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register(replicaRouterName, r.route)
+}
+
+func (r *replicaRouter) route(db *gorm.DB) {
+	stmt := db.Statement
+	// Transactions and pinned connections are not *sql.DB.
+	if _, ok := stmt.ConnPool.(*sql.DB); !ok {
+		return
+	}
+	if _, locking := stmt.Clauses["FOR"]; locking || readYourWrites(stmt.Context) {
+		return
+	}
+	// Queries built by GORM have no SQL yet; raw statements do.
+	if raw := stmt.SQL.String(); raw != "" && (!rawReplicaReads(stmt.Context) || !isPlainSelect(raw)) {
+		return
+	}
+	if pool := r.pick(); pool != nil {
+		stmt.ConnPool = pool
+	}
+}
+
+// lockingClauses make a SELECT take row locks, once whitespace is collapsed.
+var lockingClauses = []string{
+	" FOR UPDATE", " FOR NO KEY UPDATE", " FOR SHARE", " FOR KEY SHARE", " LOCK IN SHARE MODE",
+}
+
+// isPlainSelect reports whether raw SQL is a SELECT without row locks.
+func isPlainSelect(raw string) bool {
+	raw = strings.ToUpper(strings.Join(strings.Fields(raw), " "))
+	if !strings.HasPrefix(raw, "SELECT ") {
+		return false
+	}
+	for _, clause := range lockingClauses {
+		if strings.Contains(raw, clause) {
+			return false
+		}
+	}
+	return true
+}
+
+// pick returns a healthy replica according to the policy, or nil.
+func (r *replicaRouter) pick() *sql.DB {
+	healthy := make([]*sql.DB, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		if pool := rep.pool.Load(); pool != nil && rep.healthy.Load() {
+			healthy = append(healthy, pool)
+		}
+	}
+	if len(healthy) == 0 {
+		return nil
+	}
+	if r.random {
+		return healthy[rand.IntN(len(healthy))]
+	}
+	return healthy[(r.next.Add(1)-1)%uint64(len(healthy))]
+}
+
+// check pings every replica and updates the rotation.
+func (r *replicaRouter) check() {
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := rep.check(r.timeout)
+			healthy := err == nil
+			if rep.healthy.Swap(healthy) == healthy {
+				return
+			}
+			if healthy {
+				slog.Info("replica added to rotation", "replica", rep.addr)
+			} else {
+				slog.Warn("replica removed from rotation", "replica", rep.addr, "error", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// start checks the replicas once and then every interval until close.
+func (r *replicaRouter) start() {
+	r.check()
+	go r.watch()
+}
+
+func (r *replicaRouter) watch() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.check()
+		}
+	}
+}
+
+func (r *replicaRouter) close() error {
+	r.stopOnce.Do(func() { close(r.stop) })
+	<-r.done
+
+	var errs []error
+	for _, rep := range r.replicas {
+		if pool := rep.pool.Load(); pool != nil {
+			errs = append(errs, pool.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
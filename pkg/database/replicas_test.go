@@ -0,0 +1,168 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shuv1824/go-api-starter/internal/config"
+	"gorm.io/gorm"
+)
+
+// openNamedSQLite opens a SQLite file holding a single row naming it, so
+// tests can tell which database answered a query.
+func openNamedSQLite(t *testing.T, name string) *gorm.DB {
+	t.Helper()
+	cfg := config.Default().Database
+	cfg.Type = string(SQLite)
+	cfg.DbName = filepath.Join(t.TempDir(), name+".db")
+
+	db, err := NewDatabase(&cfg)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", name, err)
+	}
+	if err := db.Exec("CREATE TABLE items (name TEXT)").Error; err != nil {
+		t.Fatalf("failed to create table: %v", err)
+	}
+	if err := db.Exec("INSERT INTO items (name) VALUES (?)", name).Error; err != nil {
+		t.Fatalf("failed to insert row: %v", err)
+	}
+	return db
+}
+
+// setupReplicas routes reads of a primary to the named replicas.
+func setupReplicas(t *testing.T, policy string, names ...string) (*gorm.DB, *replicaRouter) {
+	t.Helper()
+	primary := openNamedSQLite(t, "primary")
+	router := newReplicaRouter(config.ReplicasConfig{Policy: policy, HealthCheckInterval: time.Hour}, time.Second)
+	for _, name := range names {
+		replica := openNamedSQLite(t, name)
+		router.add(name, func() (*sql.DB, error) { return replica.DB() })
+	}
+	if err := primary.Use(router); err != nil {
+		t.Fatalf("failed to register replicas: %v", err)
+	}
+	router.start()
+	t.Cleanup(func() { Close(primary) })
+	return primary, router
+}
+
+func answeredBy(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var name string
+	if err := db.Table("items").Select("name").Take(&name).Error; err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	return name
+}
+
+func TestReplicas_Routing(t *testing.T) {
+	db, _ := setupReplicas(t, "round_robin", "replica")
+
+	if got := answeredBy(t, db); got != "replica" {
+		t.Errorf("expected read from replica, got %s", got)
+	}
+
+	rawTests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{name: "raw select", ctx: context.Background(), want: "primary"},
+		{name: "raw select with WithRawReplicaReads", ctx: WithRawReplicaReads(context.Background()), want: "replica"},
+	}
+	for _, tt := range rawTests {
+		var raw string
+		if err := db.WithContext(tt.ctx).Raw("SELECT name FROM items").Scan(&raw).Error; err != nil {
+			t.Fatalf("%s: raw query failed: %v", tt.name, err)
+		}
+		if raw != tt.want {
+			t.Errorf("%s: expected read from %s, got %s", tt.name, tt.want, raw)
+		}
+	}
+
+	ctx := WithReadYourWrites(context.Background())
+	if got := answeredBy(t, db.WithContext(ctx)); got != "primary" {
+		t.Errorf("expected read-your-writes read from primary, got %s", got)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if got := answeredBy(t, tx); got != "primary" {
+			t.Errorf("expected read in transaction from primary, got %s", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+
+	if err := db.Exec("INSERT INTO items (name) VALUES ('written')").Error; err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+	var count int64
+	if err := db.WithContext(ctx).Table("items").Where("name = ?", "written").Count(&count).Error; err != nil {
+		t.Fatalf("count failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected write to go to primary, found %d rows", count)
+	}
+}
+
+func TestIsPlainSelect(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{sql: "SELECT name FROM items", want: true},
+		{sql: "  select\tname from items", want: true},
+		{sql: "SELECT id FROM users WHERE id = 1 FOR UPDATE", want: false},
+		{sql: "SELECT id FROM users\nFOR\tSHARE", want: false},
+		{sql: "SELECT id FROM users FOR NO KEY UPDATE", want: false},
+		{sql: "SELECT id FROM users LOCK IN SHARE MODE", want: false},
+		{sql: "INSERT INTO items (name) VALUES ('x')", want: false},
+		{sql: "SELECTED", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isPlainSelect(tt.sql); got != tt.want {
+			t.Errorf("isPlainSelect(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
+
+func TestReplicas_UnhealthyReplicaLeavesRotation(t *testing.T) {
+	db, router := setupReplicas(t, "round_robin", "replica")
+
+	if err := router.replicas[0].pool.Load().Close(); err != nil {
+		t.Fatalf("failed to close replica: %v", err)
+	}
+	router.check()
+
+	if router.replicas[0].healthy.Load() {
+		t.Error("expected closed replica to be unhealthy")
+	}
+	if got := answeredBy(t, db); got != "primary" {
+		t.Errorf("expected fallback to primary, got %s", got)
+	}
+}
+
+func TestReplicas_RoundRobin(t *testing.T) {
+	_, router := setupReplicas(t, "round_robin", "replica-1", "replica-2")
+
+	first, second, third := router.pick(), router.pick(), router.pick()
+	if first == second {
+		t.Error("expected consecutive reads to use different replicas")
+	}
+	if first != third {
+		t.Error("expected round robin to cycle through the replicas")
+	}
+
+	router.replicas[0].healthy.Store(false)
+	for range 3 {
+		if got := router.pick(); got != router.replicas[1].pool.Load() {
+			t.Error("expected only the healthy replica to be picked")
+		}
+	}
+}
@@ -1,54 +1,73 @@
 package database
 
 import (
-	"fmt"
-	"log/slog"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 
-	"github.com/shuv1824/go-api-starter/internal/config"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-type SQLiteDB struct{}
-
-func (s *SQLiteDB) Connect(cfg *config.DatabaseConfig) (*gorm.DB, error) {
-	dialector := s.GetDialector(cfg)
-	db, err := gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+func init() {
+	Register(string(SQLite), Driver{
+		Name:      "SQLite",
+		Dialect:   "sqlite",
+		Embedded:  true,
+		Configure: ConfigureSQLite,
+		Dialector: func(cfg *ConnConfig) (gorm.Dialector, error) {
+			return sqlite.Open(sqliteDSN(cfg)), nil
+		},
 	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to SQLite: %w", err)
-	}
+}
 
-	if err := s.testConnection(db); err != nil {
-		return nil, err
+// ConfigureSQLite limits the pool of an in-memory database to a single
+// connection. Every connection to :memory: opens a separate, empty
+// database, so the pool must hold on to exactly one. Other SQLite drivers
+// can reuse it as their Configure.
+func ConfigureSQLite(cfg *ConnConfig) {
+	if sqlitePath(cfg) != ":memory:" {
+		return
 	}
-
-	slog.Info("successfully connected to SQLite database")
-	return db, nil
+	cfg.MaxOpenConns = 1
+	cfg.MaxIdleConns = 1
+	cfg.ConnMaxLifetime = 0
+	cfg.ConnMaxIdleTime = 0
 }
 
-func (s *SQLiteDB) GetDialector(cfg *config.DatabaseConfig) gorm.Dialector {
-	// For SQLite, we use DbName as the file path
-	// If DbName is empty or ":memory:", use in-memory database
-	dbPath := cfg.DbName
-	if dbPath == "" || dbPath == ":memory:" {
-		dbPath = ":memory:"
+// sqliteDSN appends the busy timeout and options as query parameters to the
+// database path.
+func sqliteDSN(cfg *ConnConfig) string {
+	if cfg.URL != "" {
+		return cfg.URL
 	}
-	return sqlite.Open(dbPath)
-}
 
-func (s *SQLiteDB) testConnection(db *gorm.DB) error {
-	sqlDB, err := db.DB()
-	if err != nil {
-		return fmt.Errorf("failed to get database instance: %w", err)
+	params := url.Values{}
+	if cfg.StatementTimeout > 0 {
+		// SQLite has no statement timeout; wait this long for locks instead.
+		params.Set("_busy_timeout", strconv.FormatInt(cfg.StatementTimeout.Milliseconds(), 10))
+	}
+	for _, key := range slices.Sorted(maps.Keys(cfg.Options)) {
+		params.Set(key, cfg.Options[key])
 	}
 
-	// Test connection
-	if err := sqlDB.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	dsn := sqlitePath(cfg)
+	if len(params) == 0 {
+		return dsn
+	}
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + params.Encode()
 	}
+	return dsn + "?" + params.Encode()
+}
 
-	return nil
+// sqlitePath returns the database file, which for SQLite is DbName. If DbName
+// is empty or ":memory:", an in-memory database is used.
+func sqlitePath(cfg *ConnConfig) string {
+	if cfg.DbName == "" || cfg.DbName == ":memory:" {
+		return ":memory:"
+	}
+	return cfg.DbName
 }
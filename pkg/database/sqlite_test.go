@@ -0,0 +1,56 @@
+package database
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *ConnConfig)
+		want   string
+	}{
+		{
+			name:   "in memory",
+			modify: func(cfg *ConnConfig) {},
+			want:   ":memory:",
+		},
+		{
+			name: "busy timeout and options",
+			modify: func(cfg *ConnConfig) {
+				cfg.DbName = "data/app.db"
+				cfg.StatementTimeout = time.Second
+				cfg.Options = map[string]string{"_foreign_keys": "on", "_journal_mode": "WAL"}
+			},
+			want: "data/app.db?_busy_timeout=1000&_foreign_keys=on&_journal_mode=WAL",
+		},
+		{
+			name: "uri with parameters",
+			modify: func(cfg *ConnConfig) {
+				cfg.DbName = "file:app.db?cache=shared"
+				cfg.Options = map[string]string{"mode": "rwc"}
+			},
+			want: "file:app.db?cache=shared&mode=rwc",
+		},
+		{
+			name: "url",
+			modify: func(cfg *ConnConfig) {
+				cfg.URL = "file:test.db?mode=ro"
+			},
+			want: "file:test.db?mode=ro",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := defaultConnConfig()
+			cfg.DbName = ""
+			tt.modify(&cfg)
+
+			if got := sqliteDSN(&cfg); got != tt.want {
+				t.Errorf("expected DSN %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
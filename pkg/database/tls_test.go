@@ -0,0 +1,71 @@
+package database
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCA writes a self-signed CA certificate and returns its path.
+func writeTestCA(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("failed to write certificate: %v", err)
+	}
+	return path
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	files := TLSFiles{CAFile: writeTestCA(t)}
+
+	full, err := newTLSConfig("verify-full", files, "db.internal")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if full.InsecureSkipVerify || full.ServerName != "db.internal" || full.RootCAs == nil {
+		t.Error("expected verify-full to check the chain and host name")
+	}
+
+	ca, err := newTLSConfig("verify-ca", files, "db.internal")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ca.VerifyConnection == nil {
+		t.Error("expected verify-ca to verify the chain itself")
+	}
+
+	notPEM := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(notPEM, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	if _, err := newTLSConfig("verify-ca", TLSFiles{CAFile: notPEM}, ""); err == nil {
+		t.Error("expected error for a ca_file without certificates")
+	}
+}